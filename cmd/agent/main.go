@@ -0,0 +1,37 @@
+//go:build linux
+
+// Agent membaca /proc dan /sys sehingga hanya dibangun untuk linux
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"monitoring/internal/agent"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	configPath := flag.String("config", "/etc/monitoring-agent/agent.env", "path file konfigurasi (format .env), environment variable tetap diutamakan")
+	flag.Parse()
+
+	cfg, err := agent.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load agent config: ", err)
+	}
+
+	a, err := agent.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize agent: ", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Agent started for device %s, interval %s", cfg.DeviceID, cfg.Interval)
+	if err := a.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -5,6 +5,12 @@ import (
 	"monitoring/config"
 	"monitoring/internal/server"
 	database "monitoring/pkg/db"
+	"monitoring/pkg/jwt"
+	"monitoring/pkg/spool"
+	"monitoring/pkg/storage"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -33,11 +39,32 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// Initialize MQTT client
-	
+	jwtService, err := jwt.NewJwtService(&cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to initialize JWT service:", err)
+	}
+
+	artifactStore, err := storage.NewLocalStore(cfg.Firmware.StorageDir)
+	if err != nil {
+		log.Fatal("Failed to initialize firmware storage:", err)
+	}
+
+	// Spool disk untuk telemetry yang gagal ditulis saat InfluxDB tidak tersedia
+	var telemetrySpool *spool.Spool
+	if cfg.InfluxDB.SpoolMaxSize > 0 {
+		telemetrySpool, err = spool.Open(cfg.InfluxDB.SpoolDir, int64(cfg.InfluxDB.SpoolSegmentSize), int64(cfg.InfluxDB.SpoolMaxSize))
+		if err != nil {
+			log.Fatal("Failed to open telemetry spool:", err)
+		}
+	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
+		// body di atas batas default tidak ditolak fasthttp tetapi di-stream, batasnya ditegakkan
+		// middleware.BodyLimit agar hanya upload firmware yang boleh melebihi batas default.
+		// Multipart tidak di-parse sebelum middleware berjalan, file upload ditulis ke file sementara.
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -55,9 +82,32 @@ func main() {
 	app.Use(cors.New())
 
 	// Routes
-	server.SetupRoutes(app, cfg, db, influxClient, redis0)
+	mqttClient := server.SetupRoutes(app, cfg, db, influxClient, redis0, jwtService, artifactStore, telemetrySpool)
+
+	// Graceful shutdown agar batch telemetry yang masih di buffer sempat dikirim ke InfluxDB
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+		log.Println("Shutting down server...")
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Failed to shutdown server: %v", err)
+		}
+	}()
 
 	// Start server
 	log.Printf("Server starting on %s:%s", cfg.Server.Host, cfg.Server.Port)
-	log.Fatal(app.Listen(cfg.Server.Host + ":" + cfg.Server.Port))
+	if err := app.Listen(cfg.Server.Host + ":" + cfg.Server.Port); err != nil {
+		log.Fatal(err)
+	}
+
+	// Antrean telemetry MQTT dihabiskan dulu, lalu Close mem-flush semua write API asinkron
+	// sebelum koneksi ditutup dan spool disegel
+	mqttClient.Stop()
+	influxClient.Close()
+	if telemetrySpool != nil {
+		if err := telemetrySpool.Close(); err != nil {
+			log.Printf("Failed to close telemetry spool: %v", err)
+		}
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,6 +18,10 @@ type Config struct {
 	Redis    RedisConfig
 	JWT      JWTConfig
 	MQTT     MQTTConfig
+	Webhook  WebhookConfig
+	Device   DeviceConfig
+	Firmware FirmwareConfig
+	Admin    AdminConfig
 }
 
 type ServerConfig struct {
@@ -38,6 +43,23 @@ type InfluxDBConfig struct {
 	Token  string
 	Org    string
 	Bucket string
+
+	// Penulisan asinkron di-batch oleh client, batch dikirim saat penuh atau setiap FlushInterval
+	BatchSize     int
+	FlushInterval time.Duration
+	// Retry dengan backoff eksponensial, berlaku untuk penulisan asinkron maupun blocking
+	MaxRetries       int
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	// RetryBufferLimit adalah jumlah titik maksimal yang ditahan untuk retry, titik tertua dibuang jika penuh
+	RetryBufferLimit int
+
+	// Spool menampung batch asinkron yang gagal ditulis ke disk, SpoolMaxSize <= 0 mematikan spool.
+	// Spool hanya bekerja jika MaxRetries > 0 karena kegagalan dilaporkan lewat callback retry client.
+	SpoolDir            string
+	SpoolMaxSize        int // batas total ukuran segment dalam byte, segment tertua dibuang jika terlewati
+	SpoolSegmentSize    int
+	SpoolReplayInterval time.Duration
 }
 
 type RedisConfig struct {
@@ -50,6 +72,10 @@ type JWTConfig struct {
 	SecretKey            string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
+	Issuer               string
+	Algorithm            string            // HS256, RS256 atau ES256
+	SigningKeyID         string            // kid key yang dipakai menandatangani token baru
+	PrivateKeys          map[string]string // kid -> path file PEM
 }
 
 type MQTTConfig struct {
@@ -58,6 +84,40 @@ type MQTTConfig struct {
 	Username string
 	Password string
 	Topic    string
+
+	// Telemetry diproses worker pool terpisah dari callback MQTT. Pesan satu perangkat selalu
+	// ditangani worker yang sama sehingga urutannya terjaga.
+	IngestWorkers    int
+	IngestQueueSize  int    // total kapasitas antrean, dibagi rata ke setiap worker
+	IngestFullPolicy string // "drop" membuang pesan baru saat antrean penuh, "block" menahan callback MQTT dulu
+	// IngestBlockTimeout membatasi lama callback tertahan dengan policy block, harus jauh di bawah keepalive
+	IngestBlockTimeout time.Duration
+}
+
+type WebhookConfig struct {
+	Workers     int
+	MaxAttempts int
+	Timeout     time.Duration
+	BaseBackoff time.Duration
+}
+
+type DeviceConfig struct {
+	OfflineAfter         time.Duration
+	SweepInterval        time.Duration
+	CommandSweepInterval time.Duration
+}
+
+// AdminConfig adalah akun admin awal yang dibuat saat startup jika belum ada, kosongkan Username untuk melewati
+type AdminConfig struct {
+	Username string
+	Email    string
+	Password string
+}
+
+type FirmwareConfig struct {
+	StorageDir string
+	MaxSize    int    // ukuran maksimal upload artifact dalam byte
+	BaseURL    string // URL publik server, dipakai untuk link download yang dikirim ke perangkat
 }
 
 func Load() *Config {
@@ -84,6 +144,18 @@ func Load() *Config {
 			Token:  getEnv("INFLUX_TOKEN", ""),
 			Org:    getEnv("INFLUX_ORG", "iot-org"),
 			Bucket: getEnv("INFLUX_BUCKET", "monitoring"),
+
+			BatchSize:        getEnvAsInt("INFLUX_BATCH_SIZE", 500),
+			FlushInterval:    getEnvAsDuration("INFLUX_FLUSH_INTERVAL", "1s"),
+			MaxRetries:       getEnvAsInt("INFLUX_MAX_RETRIES", 3),
+			RetryInterval:    getEnvAsDuration("INFLUX_RETRY_INTERVAL", "1s"),
+			MaxRetryInterval: getEnvAsDuration("INFLUX_MAX_RETRY_INTERVAL", "30s"),
+			RetryBufferLimit: getEnvAsInt("INFLUX_RETRY_BUFFER_LIMIT", 50000),
+
+			SpoolDir:            getEnv("INFLUX_SPOOL_DIR", "./data/spool"),
+			SpoolMaxSize:        getEnvAsInt("INFLUX_SPOOL_MAX_SIZE", 1<<30),
+			SpoolSegmentSize:    getEnvAsInt("INFLUX_SPOOL_SEGMENT_SIZE", 16<<20),
+			SpoolReplayInterval: getEnvAsDuration("INFLUX_SPOOL_REPLAY_INTERVAL", "10s"),
 		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -91,9 +163,13 @@ func Load() *Config {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			SecretKey:            getEnv("JWT_SECRET", "your-secret-key"),
+			SecretKey:            getEnv("JWT_SECRET", ""), // wajib untuk HS256, minimal 32 byte
 			AccessTokenDuration:  getEnvAsDuration("JWT_ACCESS_DURATION", "15m"),
-			RefreshTokenDuration: getEnvAsDuration("JWT_REFRESH_DURATION", "7d"),
+			RefreshTokenDuration: getEnvAsDuration("JWT_REFRESH_DURATION", "168h"),
+			Issuer:               getEnv("JWT_ISSUER", "go-monitoring"),
+			Algorithm:            getEnv("JWT_ALGORITHM", "HS256"),
+			SigningKeyID:         getEnv("JWT_SIGNING_KEY_ID", ""),
+			PrivateKeys:          getEnvAsMap("JWT_KEYS"),
 		},
 		MQTT: MQTTConfig{
 			Broker:   getEnv("MQTT_BROKER", "localhost"),
@@ -101,6 +177,32 @@ func Load() *Config {
 			Username: getEnv("MQTT_USERNAME", ""),
 			Password: getEnv("MQTT_PASSWORD", ""),
 			Topic:    getEnv("MQTT_TOPIC", "iot/monitoring"),
+
+			IngestWorkers:      getEnvAsInt("MQTT_INGEST_WORKERS", 8),
+			IngestQueueSize:    getEnvAsInt("MQTT_INGEST_QUEUE_SIZE", 10000),
+			IngestFullPolicy:   getEnv("MQTT_INGEST_FULL_POLICY", "drop"),
+			IngestBlockTimeout: getEnvAsDuration("MQTT_INGEST_BLOCK_TIMEOUT", "1s"),
+		},
+		Admin: AdminConfig{
+			Username: getEnv("ADMIN_USERNAME", ""),
+			Email:    getEnv("ADMIN_EMAIL", ""),
+			Password: getEnv("ADMIN_PASSWORD", ""),
+		},
+		Webhook: WebhookConfig{
+			Workers:     getEnvAsInt("WEBHOOK_WORKERS", 4),
+			MaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 5),
+			Timeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", "10s"),
+			BaseBackoff: getEnvAsDuration("WEBHOOK_BASE_BACKOFF", "2s"),
+		},
+		Device: DeviceConfig{
+			OfflineAfter:         getEnvAsDuration("DEVICE_OFFLINE_AFTER", "2m"),
+			SweepInterval:        getEnvAsDuration("DEVICE_SWEEP_INTERVAL", "30s"),
+			CommandSweepInterval: getEnvAsDuration("DEVICE_COMMAND_SWEEP_INTERVAL", "10s"),
+		},
+		Firmware: FirmwareConfig{
+			StorageDir: getEnv("FIRMWARE_STORAGE_DIR", "./data/firmware"),
+			MaxSize:    getEnvAsInt("FIRMWARE_MAX_SIZE", 256<<20),
+			BaseURL:    getEnv("FIRMWARE_BASE_URL", "http://localhost:8080"),
 		},
 	}
 }
@@ -130,3 +232,15 @@ func getEnvAsDuration(key string, defaultValue string) time.Duration {
 	duration, _ := time.ParseDuration(defaultValue)
 	return duration
 }
+
+// getEnvAsMap membaca format "key1=value1,key2=value2"
+func getEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const publishTimeout = 10 * time.Second
+
+// Agent mengumpulkan metric secara berkala dan mempublikasikannya ke {topic}/{device_id}/telemetry.
+// Reading yang gagal dikirim disimpan di Buffer dan dikirim ulang berurutan setelah tersambung.
+type Agent struct {
+	cfg       *Config
+	client    mqtt.Client
+	collector *Collector
+	buffer    *Buffer
+	connected chan struct{}
+}
+
+func New(cfg *Config) (*Agent, error) {
+	buffer, err := NewBuffer(cfg.BufferDir, cfg.BufferMax)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Agent{
+		cfg:       cfg,
+		collector: NewCollector(cfg),
+		buffer:    buffer,
+		connected: make(chan struct{}, 1),
+	}
+
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(fmt.Sprintf("tcp://%s:%s", cfg.Broker, cfg.Port))
+	opts.SetClientID("agent-" + cfg.DeviceID.String())
+	if cfg.Username != "" {
+		opts.SetUsername(cfg.Username)
+		opts.SetPassword(cfg.Password)
+	}
+	// server menandai perangkat offline dari Last Will ini saat koneksi terputus tidak wajar
+	opts.SetWill(a.topic("status"), "offline", 1, true)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetConnectRetryInterval(5 * time.Second)
+	opts.SetOnConnectHandler(a.onConnect)
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("Connection to MQTT broker lost: %v", err)
+	})
+	a.client = mqtt.NewClient(opts)
+
+	return a, nil
+}
+
+// Run blocking sampai ctx selesai, lalu mengirim status offline dan memutus koneksi
+func (a *Agent) Run(ctx context.Context) error {
+	// dengan ConnectRetry, Connect terus mencoba di background sehingga tidak perlu ditunggu
+	a.client.Connect()
+	if n := a.buffer.Len(); n > 0 {
+		log.Printf("%d buffered readings will be sent once connected", n)
+	}
+
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if a.client.IsConnected() {
+				a.publish(a.topic("status"), true, []byte("offline"))
+			}
+			a.client.Disconnect(250)
+			return nil
+		case <-a.connected:
+			a.drain()
+		case <-ticker.C:
+			a.collect()
+		}
+	}
+}
+
+func (a *Agent) collect() {
+	data, err := a.collector.Collect()
+	if err != nil {
+		log.Printf("Failed to collect metrics: %v", err)
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode metrics: %v", err)
+		return
+	}
+
+	// reading baru hanya dikirim langsung jika buffer sudah kosong agar urutan waktu terjaga
+	if a.client.IsConnected() && a.drain() {
+		if err := a.publish(a.topic("telemetry"), false, payload); err == nil {
+			return
+		}
+	}
+	if err := a.buffer.Append(payload); err != nil {
+		log.Printf("Failed to buffer reading: %v", err)
+	}
+}
+
+// drain mengirim isi buffer, true jika buffer sudah kosong
+func (a *Agent) drain() bool {
+	sent, err := a.buffer.Drain(func(payload []byte) error {
+		return a.publish(a.topic("telemetry"), false, payload)
+	})
+	if sent > 0 {
+		log.Printf("Sent %d buffered readings", sent)
+	}
+	if err != nil {
+		log.Printf("Failed to send buffered readings: %v", err)
+		return false
+	}
+	return true
+}
+
+func (a *Agent) onConnect(client mqtt.Client) {
+	log.Println("Connected to MQTT broker")
+	if err := a.publish(a.topic("status"), true, []byte("online")); err != nil {
+		log.Printf("Failed to publish online status: %v", err)
+	}
+	select {
+	case a.connected <- struct{}{}:
+	default:
+	}
+}
+
+func (a *Agent) publish(topic string, retained bool, payload []byte) error {
+	if !a.client.IsConnected() {
+		return errors.New("not connected to MQTT broker")
+	}
+	token := a.client.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("timed out waiting for MQTT publish")
+	}
+	return token.Error()
+}
+
+func (a *Agent) topic(suffix string) string {
+	return fmt.Sprintf("%s/%s/%s", a.cfg.Topic, a.cfg.DeviceID, suffix)
+}
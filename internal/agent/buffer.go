@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Buffer menyimpan payload telemetry yang belum terkirim sebagai file JSON lines, sehingga
+// data tetap ada walaupun agent restart selama broker tidak bisa dihubungi.
+type Buffer struct {
+	mu    sync.Mutex
+	path  string
+	max   int
+	count int
+}
+
+func NewBuffer(dir string, max int) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create buffer dir: %w", err)
+	}
+	b := &Buffer{path: filepath.Join(dir, "telemetry.jsonl"), max: max}
+
+	lines, err := b.readAll()
+	if err != nil {
+		return nil, err
+	}
+	b.count = len(lines)
+	return b, nil
+}
+
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Append menambahkan satu payload, reading terlama dibuang jika buffer penuh
+func (b *Buffer) Append(payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count >= b.max {
+		lines, err := b.readAll()
+		if err != nil {
+			return err
+		}
+		drop := len(lines) - b.max + 1
+		if err := b.writeAll(append(lines[drop:], payload)); err != nil {
+			return err
+		}
+		b.count = b.max
+		return nil
+	}
+
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open buffer: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(bytes.TrimSpace(payload), '\n')); err != nil {
+		return fmt.Errorf("failed to append buffer: %w", err)
+	}
+	b.count++
+	return nil
+}
+
+// Drain mengirim isi buffer berurutan lewat send dan berhenti pada kegagalan pertama.
+// Payload yang belum terkirim tetap disimpan untuk percobaan berikutnya.
+func (b *Buffer) Drain(send func(payload []byte) error) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count == 0 {
+		return 0, nil
+	}
+	lines, err := b.readAll()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var sendErr error
+	for _, line := range lines {
+		if sendErr = send(line); sendErr != nil {
+			break
+		}
+		sent++
+	}
+
+	if err := b.writeAll(lines[sent:]); err != nil {
+		return sent, err
+	}
+	b.count = len(lines) - sent
+	return sent, sendErr
+}
+
+func (b *Buffer) readAll() ([][]byte, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open buffer: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read buffer: %w", err)
+	}
+	return lines, nil
+}
+
+// writeAll mengganti isi buffer lewat file sementara agar buffer tidak rusak jika agent mati di tengah penulisan
+func (b *Buffer) writeAll(lines [][]byte) error {
+	if len(lines) == 0 {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear buffer: %w", err)
+		}
+		return nil
+	}
+
+	tmp := b.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite buffer: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to rewrite buffer: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite buffer: %w", err)
+	}
+	return os.Rename(tmp, b.path)
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// Collector membaca metric host dari /proc, /sys/class/thermal dan statfs
+type Collector struct {
+	deviceID    uuid.UUID
+	diskPath    string
+	thermalZone string
+
+	// sampel /proc/stat sebelumnya, cpu_usage dihitung dari selisih dua sampel
+	prevIdle  uint64
+	prevTotal uint64
+}
+
+func NewCollector(cfg *Config) *Collector {
+	c := &Collector{
+		deviceID:    cfg.DeviceID,
+		diskPath:    cfg.DiskPath,
+		thermalZone: cfg.ThermalZone,
+	}
+	// sampel awal agar Collect pertama sudah punya pembanding
+	if idle, total, err := readCPUStat("/proc/stat"); err == nil {
+		c.prevIdle, c.prevTotal = idle, total
+	}
+	return c
+}
+
+// Collect mengembalikan satu reading dalam format entity.MonitoringData yang diterima server
+func (c *Collector) Collect() (*entity.MonitoringData, error) {
+	data := &entity.MonitoringData{
+		DeviceID:  c.deviceID,
+		Timestamp: time.Now().UTC(),
+	}
+
+	idle, total, err := readCPUStat("/proc/stat")
+	if err != nil {
+		return nil, err
+	}
+	if total > c.prevTotal {
+		data.CPUUsage = round2(100 * (1 - float64(idle-c.prevIdle)/float64(total-c.prevTotal)))
+	}
+	c.prevIdle, c.prevTotal = idle, total
+
+	if data.MemoryUsage, err = readMemoryUsage("/proc/meminfo"); err != nil {
+		return nil, err
+	}
+	if data.DiskUsage, err = diskUsage(c.diskPath); err != nil {
+		return nil, err
+	}
+	// tidak semua host punya sensor suhu, nilai 0 berarti tidak tersedia
+	data.Temperature, _ = readTemperature(c.thermalZone)
+
+	return data, nil
+}
+
+func readCPUStat(path string) (idle, total uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return parseCPUStat(f)
+}
+
+// parseCPUStat membaca baris "cpu" agregat. idle termasuk iowait.
+func parseCPUStat(r io.Reader) (idle, total uint64, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid /proc/stat value %q: %w", field, err)
+			}
+			// guest dan guest_nice sudah terhitung di user dan nice
+			if i >= 8 {
+				break
+			}
+			total += value
+			if i == 3 || i == 4 {
+				idle += value
+			}
+		}
+		return idle, total, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, fmt.Errorf("cpu line not found in /proc/stat")
+}
+
+func readMemoryUsage(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parseMemoryUsage(f)
+}
+
+// parseMemoryUsage menghitung persentase memori terpakai dari MemTotal dan MemAvailable
+func parseMemoryUsage(r io.Reader) (float64, error) {
+	var memTotal, memAvailable uint64
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			memTotal = value
+		case "MemAvailable:":
+			memAvailable = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if memTotal == 0 {
+		return 0, fmt.Errorf("MemTotal not found in /proc/meminfo")
+	}
+	return round2(100 * (1 - float64(memAvailable)/float64(memTotal))), nil
+}
+
+func readTemperature(path string) (float64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	milli, err := strconv.ParseFloat(strings.TrimSpace(string(raw)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature value %q: %w", raw, err)
+	}
+	return round2(milli / 1000), nil
+}
+
+func round2(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
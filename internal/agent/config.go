@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	DeviceID uuid.UUID
+
+	Broker   string
+	Port     string
+	Username string
+	Password string
+	Topic    string
+
+	Interval    time.Duration
+	DiskPath    string // mount point yang dihitung disk_usage-nya
+	ThermalZone string // file suhu dalam milidegree Celsius
+	BufferDir   string // lokasi buffer saat broker tidak bisa dihubungi
+	BufferMax   int    // jumlah maksimal reading di buffer, reading terlama dibuang
+}
+
+// LoadConfig membaca file env (jika ada) lalu environment. Variabel environment
+// yang sudah ter-set tidak ditimpa isi file.
+func LoadConfig(path string) (*Config, error) {
+	if path != "" {
+		if err := godotenv.Load(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+
+	deviceID, err := uuid.Parse(os.Getenv("AGENT_DEVICE_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("AGENT_DEVICE_ID must be the device UUID: %w", err)
+	}
+
+	interval, err := time.ParseDuration(getEnv("AGENT_INTERVAL", "10s"))
+	if err != nil || interval < time.Second {
+		return nil, fmt.Errorf("AGENT_INTERVAL must be a duration of at least 1s")
+	}
+
+	bufferMax, err := strconv.Atoi(getEnv("AGENT_BUFFER_MAX", "8640"))
+	if err != nil || bufferMax < 1 {
+		return nil, fmt.Errorf("AGENT_BUFFER_MAX must be a positive number")
+	}
+
+	return &Config{
+		DeviceID:    deviceID,
+		Broker:      getEnv("MQTT_BROKER", "localhost"),
+		Port:        getEnv("MQTT_PORT", "1883"),
+		Username:    getEnv("MQTT_USERNAME", ""),
+		Password:    getEnv("MQTT_PASSWORD", ""),
+		Topic:       getEnv("MQTT_TOPIC", "iot/monitoring"),
+		Interval:    interval,
+		DiskPath:    getEnv("AGENT_DISK_PATH", "/"),
+		ThermalZone: getEnv("AGENT_THERMAL_ZONE", "/sys/class/thermal/thermal_zone0/temp"),
+		BufferDir:   getEnv("AGENT_BUFFER_DIR", "/var/lib/monitoring-agent"),
+		BufferMax:   bufferMax,
+	}, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,17 @@
+package agent
+
+import "syscall"
+
+// diskUsage menghitung persentase terpakai seperti df: blok milik root tidak dihitung tersedia
+func diskUsage(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	used := stat.Blocks - stat.Bfree
+	capacity := used + stat.Bavail
+	if capacity == 0 {
+		return 0, nil
+	}
+	return round2(100 * float64(used) / float64(capacity)), nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package agent
+
+import "errors"
+
+func diskUsage(path string) (float64, error) {
+	return 0, errors.New("disk usage is only supported on linux")
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AlertStatePending  = "pending"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+type AlertRule struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string     `json:"name" gorm:"not null;size:100"`
+	Metric    string     `json:"metric" gorm:"not null;size:50"`  // "cpu_usage", "memory_usage", "disk_usage", "temperature"
+	Operator  string     `json:"operator" gorm:"not null;size:3"` // "gt", "gte", "lt", "lte", "eq", "neq"
+	Threshold float64    `json:"threshold" gorm:"not null"`
+	Duration  int        `json:"duration" gorm:"not null;default:0"` // detik kondisi harus bertahan sebelum firing
+	Severity  string     `json:"severity" gorm:"not null;default:'warning';size:20"`
+	DeviceID  *uuid.UUID `json:"device_id,omitempty" gorm:"type:uuid;index"` // nil (dan GroupID nil) berarti berlaku untuk semua perangkat milik user
+	GroupID   *uuid.UUID `json:"group_id,omitempty" gorm:"type:uuid;index"`  // berlaku untuk anggota group saat evaluasi
+	Enabled   bool       `json:"enabled" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type Alert struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RuleID     uuid.UUID  `json:"rule_id" gorm:"type:uuid;not null;index"`
+	DeviceID   uuid.UUID  `json:"device_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	State      string     `json:"state" gorm:"not null;size:20;index"`
+	Metric     string     `json:"metric" gorm:"not null;size:50"`
+	Severity   string     `json:"severity" gorm:"not null;size:20"`
+	Value      float64    `json:"value"`
+	Threshold  float64    `json:"threshold"`
+	StartedAt  time.Time  `json:"started_at"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Rule *AlertRule `json:"rule,omitempty" gorm:"foreignKey:RuleID"`
+}
+
+type AlertRuleRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Metric    string     `json:"metric" validate:"required,max=63"`
+	Operator  string     `json:"operator" validate:"required,oneof=gt gte lt lte eq neq"`
+	Threshold *float64   `json:"threshold" validate:"required"`
+	Duration  int        `json:"duration" validate:"min=0"`
+	Severity  string     `json:"severity" validate:"omitempty,oneof=info warning critical"`
+	DeviceID  *uuid.UUID `json:"device_id" validate:"excluded_with=GroupID"`
+	GroupID   *uuid.UUID `json:"group_id"`
+	Enabled   *bool      `json:"enabled"`
+}
+
+type AlertQuery struct {
+	State    string     `query:"state" validate:"omitempty,oneof=pending firing resolved active"`
+	DeviceID *uuid.UUID `query:"device_id"`
+	Limit    int        `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset   int        `query:"offset" validate:"omitempty,min=0"`
+
+	// UserID diisi oleh usecase untuk membatasi hasil ke alert milik user, nil berarti semua
+	UserID *uuid.UUID `query:"-"`
+}
+
+// LastStateChange adalah waktu (menurut timestamp telemetry) alert terakhir berpindah state
+func (a *Alert) LastStateChange() time.Time {
+	switch {
+	case a.ResolvedAt != nil:
+		return *a.ResolvedAt
+	case a.FiredAt != nil:
+		return *a.FiredAt
+	}
+	return a.StartedAt
+}
+
+// Matches reports whether value satisfies the rule's operator against its threshold.
+func (r *AlertRule) Matches(value float64) bool {
+	switch r.Operator {
+	case "gt":
+		return value > r.Threshold
+	case "gte":
+		return value >= r.Threshold
+	case "lt":
+		return value < r.Threshold
+	case "lte":
+		return value <= r.Threshold
+	case "eq":
+		return value == r.Threshold
+	case "neq":
+		return value != r.Threshold
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeTelemetryRead  = "telemetry:read"
+	ScopeTelemetryWrite = "telemetry:write"
+	ScopeDevicesRead    = "devices:read"
+	ScopeDevicesWrite   = "devices:write"
+	ScopeDevicesAdmin   = "devices:admin"
+	ScopeDevicesCommand = "devices:command"
+	ScopeAlertsRead     = "alerts:read"
+	ScopeAlertsWrite    = "alerts:write"
+)
+
+// APIKey adalah kredensial jangka panjang untuk script/Grafana. Plaintext key hanya
+// ditampilkan sekali saat dibuat, yang disimpan hanya hash SHA-256-nya.
+type APIKey struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string      `json:"name" gorm:"not null;size:100"`
+	Prefix     string      `json:"prefix" gorm:"not null;size:16"` // awal key untuk membedakan key di UI
+	KeyHash    string      `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	Scopes     []string    `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	DeviceIDs  []uuid.UUID `json:"device_ids,omitempty" gorm:"type:jsonb;serializer:json"` // kosong berarti semua perangkat milik user
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time  `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime"`
+
+	// Owner diisi saat autentikasi agar role pemilik ikut membatasi izin key
+	Owner *User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+type APIKeyRequest struct {
+	Name      string      `json:"name" validate:"required,max=100"`
+	Scopes    []string    `json:"scopes" validate:"required,min=1,dive,oneof=telemetry:read telemetry:write devices:read devices:write devices:admin devices:command alerts:read alerts:write"`
+	DeviceIDs []uuid.UUID `json:"device_ids"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// APIKeyCreated adalah respons pembuatan key, satu-satunya tempat plaintext key dikembalikan
+type APIKeyCreated struct {
+	*APIKey
+	Key string `json:"key"`
+}
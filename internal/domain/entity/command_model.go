@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CommandStatusQueued    = "queued"
+	CommandStatusSent      = "sent"
+	CommandStatusAcked     = "acked"
+	CommandStatusSucceeded = "succeeded"
+	CommandStatusFailed    = "failed"
+	CommandStatusTimedOut  = "timed_out"
+)
+
+// CommandPendingStatuses adalah status yang masih menunggu balasan perangkat
+var CommandPendingStatuses = []string{CommandStatusQueued, CommandStatusSent, CommandStatusAcked}
+
+// DeviceCommand adalah perintah yang dikirim ke perangkat lewat MQTT. ID-nya dipakai sebagai
+// correlation_id sehingga balasan perangkat bisa dicocokkan dengan perintahnya.
+type DeviceCommand struct {
+	ID          uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DeviceID    uuid.UUID              `json:"device_id" gorm:"type:uuid;not null;index"`
+	UserID      uuid.UUID              `json:"user_id" gorm:"type:uuid;not null;index"` // user yang mengirim perintah
+	Command     string                 `json:"command" gorm:"not null;size:64"`
+	Params      map[string]interface{} `json:"params,omitempty" gorm:"type:jsonb;serializer:json"`
+	Status      string                 `json:"status" gorm:"not null;size:20;index"`
+	Result      map[string]interface{} `json:"result,omitempty" gorm:"type:jsonb;serializer:json"`
+	Error       string                 `json:"error,omitempty" gorm:"size:500"`
+	ExpiresAt   time.Time              `json:"expires_at" gorm:"not null;index"` // setelah ini perintah yang belum selesai menjadi timed_out
+	SentAt      *time.Time             `json:"sent_at,omitempty"`
+	AckedAt     *time.Time             `json:"acked_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Done reports whether the command has reached a terminal status.
+func (c *DeviceCommand) Done() bool {
+	switch c.Status {
+	case CommandStatusSucceeded, CommandStatusFailed, CommandStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+type CommandRequest struct {
+	Command string                 `json:"command" validate:"required,max=64"` // contoh: reboot, restart_service, set_interval
+	Params  map[string]interface{} `json:"params"`
+	Timeout int                    `json:"timeout" validate:"omitempty,min=1,max=86400"` // detik, default 60
+}
+
+type CommandQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=queued sent acked succeeded failed timed_out pending"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `query:"offset" validate:"omitempty,min=0"`
+
+	// DeviceID diisi dari path oleh handler
+	DeviceID uuid.UUID `query:"-"`
+}
+
+// CommandMessage adalah payload yang dipublikasikan ke {topic}/{device_id}/commands
+type CommandMessage struct {
+	CorrelationID uuid.UUID              `json:"correlation_id"`
+	Command       string                 `json:"command"`
+	Params        map[string]interface{} `json:"params,omitempty"`
+	IssuedAt      time.Time              `json:"issued_at"`
+	ExpiresAt     time.Time              `json:"expires_at"`
+}
+
+// CommandResponse adalah balasan perangkat di {topic}/{device_id}/commands/response.
+// Perangkat boleh mengirim "acked" lebih dulu lalu "succeeded"/"failed" setelah selesai.
+type CommandResponse struct {
+	CorrelationID uuid.UUID              `json:"correlation_id"`
+	Status        string                 `json:"status"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
@@ -14,24 +14,97 @@ type Device struct {
 	IPAddress  string    `json:"ip_address" gorm:"not null;size:15"`
 	Location   string    `json:"location" gorm:"not null;size:200"`
 	IsOnline   bool      `json:"is_online" gorm:"default:false"`
-	LastSeen   time.Time `json:"last_seen" gorm:"autoUpdateTime"`
+	LastSeen   time.Time `json:"last_seen" gorm:"autoCreateTime"` // diperbarui oleh heartbeat, bukan setiap update
 	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash  *string   `json:"-" gorm:"uniqueIndex;size:64"` // hash SHA-256 token perangkat, nil jika belum diprovisioning
 	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
+	// Tags adalah label key/value bebas, ikut ditulis sebagai tag InfluxDB "tag_<key>"
+	Tags map[string]string `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	// FirmwareVersion diisi setelah perangkat melaporkan rollout OTA berhasil
+	FirmwareVersion string `json:"firmware_version,omitempty" gorm:"size:50"`
+
 	// Relationships
-	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	User   *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Groups []*DeviceGroup `json:"groups,omitempty" gorm:"many2many:device_group_members"`
 }
 
 type DeviceRequest struct {
 	Name       string `json:"name" validate:"required"`
 	Type       string `json:"type" validate:"required,oneof=raspberry_pi mini_pc"`
-	MacAddress string `json:"mac_address" validate:"required"`
+	MacAddress string `json:"mac_address" validate:"required,mac"`
 	IPAddress  string `json:"ip_address" validate:"required,ip"`
 	Location   string `json:"location" validate:"required"`
+
+	Tags map[string]string `json:"tags" validate:"omitempty,max=20,dive,keys,min=1,max=50,endkeys,max=100"`
 }
 
 type DeviceResponse struct {
 	*Device
 	MonitoringData *MonitoringData `json:"monitoring_data,omitempty"`
 }
+
+// DeviceCredential dikembalikan saat provisioning atau rotasi token, satu-satunya tempat token plaintext terlihat
+type DeviceCredential struct {
+	Device *Device `json:"device"`
+	Token  string  `json:"token"`
+}
+
+// IngestReading adalah satu pembacaan telemetry yang dikirim perangkat lewat HTTP ingest.
+// Timestamp diisi perangkat (misalnya data yang sempat tertahan saat offline), kosong berarti waktu server.
+type IngestReading struct {
+	CPUUsage    float64    `json:"cpu_usage" validate:"gte=0,lte=100"`
+	MemoryUsage float64    `json:"memory_usage" validate:"gte=0,lte=100"`
+	DiskUsage   float64    `json:"disk_usage" validate:"gte=0,lte=100"`
+	Temperature float64    `json:"temperature"`
+	Timestamp   *time.Time `json:"timestamp"`
+
+	// Fields berisi metric tambahan, divalidasi terhadap katalog metric saat disimpan
+	Fields map[string]interface{} `json:"fields" validate:"omitempty,max=64"`
+}
+
+// IngestBatch membungkus beberapa pembacaan agar bisa divalidasi sekaligus
+type IngestBatch struct {
+	Readings []IngestReading `validate:"required,min=1,max=1000,dive"`
+}
+
+// DeviceUpdateRequest dipakai untuk PATCH, field yang nil tidak diubah
+type DeviceUpdateRequest struct {
+	Name       *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Type       *string `json:"type" validate:"omitempty,oneof=raspberry_pi mini_pc"`
+	MacAddress *string `json:"mac_address" validate:"omitempty,mac"`
+	IPAddress  *string `json:"ip_address" validate:"omitempty,ip"`
+	Location   *string `json:"location" validate:"omitempty,min=1,max=200"`
+
+	// Tags mengganti seluruh tag perangkat jika dikirim, kirim {} untuk menghapus semua tag
+	Tags map[string]string `json:"tags" validate:"omitempty,max=20,dive,keys,min=1,max=50,endkeys,max=100"`
+}
+
+type DeviceListQuery struct {
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset   int    `query:"offset" validate:"omitempty,min=0"`
+	Cursor   string `query:"cursor"`
+	Type     string `query:"type" validate:"omitempty,oneof=raspberry_pi mini_pc"`
+	Location string `query:"location"`
+	IsOnline *bool  `query:"is_online"`
+	SortBy   string `query:"sort_by" validate:"omitempty,oneof=name type location created_at updated_at last_seen"`
+	Order    string `query:"order" validate:"omitempty,oneof=asc desc"`
+	// GroupID membatasi ke anggota group, Tags berformat key:value dan semuanya harus cocok
+	GroupID *uuid.UUID `query:"group_id"`
+	Tags    []string   `query:"tag" validate:"omitempty,max=10,dive,contains=:"`
+
+	// UserID diisi oleh usecase untuk membatasi hasil ke perangkat milik user, nil berarti semua
+	UserID *uuid.UUID `query:"-"`
+	// IDs diisi dari batasan perangkat API key, kosong berarti tidak dibatasi
+	IDs []uuid.UUID `query:"-"`
+}
+
+type DeviceList struct {
+	Devices    []*Device `json:"data"`
+	Total      int64     `json:"total"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
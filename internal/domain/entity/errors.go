@@ -0,0 +1,32 @@
+package entity
+
+import "errors"
+
+var (
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrNoTelemetry       = errors.New("no telemetry data found")
+	ErrInvalidQuery      = errors.New("invalid query")
+	ErrRuleNotFound      = errors.New("alert rule not found")
+	ErrChannelNotFound   = errors.New("notification channel not found")
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+	ErrInvalidAPIKey     = errors.New("invalid or expired api key")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrInvalidDevice     = errors.New("invalid device token")
+	ErrDeviceExists      = errors.New("device already registered")
+	ErrProvisionNotFound = errors.New("provisioning request not found or expired")
+	ErrProvisionConflict = errors.New("provision_id already used by another device")
+	ErrGroupNotFound     = errors.New("device group not found")
+	ErrGroupExists       = errors.New("device group already exists")
+	ErrCommandNotFound   = errors.New("device command not found")
+	ErrShadowConflict    = errors.New("shadow version conflict")
+	ErrFirmwareNotFound  = errors.New("firmware not found")
+	ErrFirmwareExists    = errors.New("firmware version already exists")
+	ErrRolloutNotFound   = errors.New("firmware rollout not found")
+	ErrMetricNotFound    = errors.New("metric definition not found")
+	ErrMetricExists      = errors.New("metric definition already exists")
+	ErrTelemetryWrite    = errors.New("failed to write telemetry")
+	ErrTooManyRequests   = errors.New("too many requests")
+)
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventDeviceOnline  = "device.online"
+	EventDeviceOffline = "device.offline"
+	EventAlertFiring   = "alert.firing"
+	EventAlertResolved = "alert.resolved"
+	EventTokenReuse    = "security.token_reuse"
+	EventRolloutHalted = "firmware.rollout_halted"
+	EventRolloutDone   = "firmware.rollout_completed"
+	EventTest          = "test"
+)
+
+// Event adalah kejadian domain yang dikirim ke channel notifikasi milik UserID
+type Event struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       string      `json:"type"`
+	UserID     uuid.UUID   `json:"user_id"`
+	DeviceID   *uuid.UUID  `json:"device_id,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
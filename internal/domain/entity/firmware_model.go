@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RolloutStatusRunning   = "running"
+	RolloutStatusHalted    = "halted"
+	RolloutStatusCompleted = "completed"
+	RolloutStatusCancelled = "cancelled"
+)
+
+const (
+	OTAStatusPending     = "pending" // belum masuk stage yang dirilis
+	OTAStatusNotified    = "notified"
+	OTAStatusDownloading = "downloading"
+	OTAStatusInstalling  = "installing"
+	OTAStatusSucceeded   = "succeeded"
+	OTAStatusFailed      = "failed"
+)
+
+// OTAInProgressStatuses adalah status perangkat yang sudah dirilis tapi belum selesai
+var OTAInProgressStatuses = []string{OTAStatusNotified, OTAStatusDownloading, OTAStatusInstalling}
+
+// Firmware adalah artifact agent/firmware yang diunggah untuk satu tipe perangkat
+type Firmware struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_firmware_version"`
+	Version    string    `json:"version" gorm:"not null;size:50;uniqueIndex:idx_firmware_version"`
+	DeviceType string    `json:"device_type" gorm:"not null;size:50;uniqueIndex:idx_firmware_version"`
+	Checksum   string    `json:"checksum" gorm:"not null;size:64"` // SHA-256 hex
+	Size       int64     `json:"size" gorm:"not null"`
+	StorageKey string    `json:"-" gorm:"not null;size:100"`
+	Notes      string    `json:"notes,omitempty" gorm:"size:500"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type FirmwareRequest struct {
+	Version    string `form:"version" validate:"required,max=50"`
+	DeviceType string `form:"device_type" validate:"required,oneof=raspberry_pi mini_pc"`
+	Checksum   string `form:"checksum" validate:"omitempty,len=64,hexadecimal"` // jika diisi harus sama dengan hasil hitung server
+	Notes      string `form:"notes" validate:"max=500"`
+}
+
+// FirmwareRollout merilis satu firmware bertahap. Stages berisi persentase kumulatif target,
+// misalnya [10, 50, 100]; stage berikutnya dirilis setelah semua perangkat stage saat ini selesai.
+type FirmwareRollout struct {
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;index"`
+	FirmwareID       uuid.UUID   `json:"firmware_id" gorm:"type:uuid;not null;index"`
+	Status           string      `json:"status" gorm:"not null;size:20;index"`
+	Stages           []int       `json:"stages" gorm:"type:jsonb;serializer:json;not null"`
+	CurrentStage     int         `json:"current_stage" gorm:"not null;default:0"`
+	FailureThreshold float64     `json:"failure_threshold" gorm:"not null"` // persen perangkat gagal dari yang sudah dirilis
+	DeviceIDs        []uuid.UUID `json:"device_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	GroupIDs         []uuid.UUID `json:"group_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	HaltReason       string      `json:"halt_reason,omitempty" gorm:"size:200"`
+	CompletedAt      *time.Time  `json:"completed_at,omitempty"`
+	CreatedAt        time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Firmware *Firmware `json:"firmware,omitempty" gorm:"foreignKey:FirmwareID"`
+
+	// Stats dan Devices diisi saat rollout dibaca satu per satu
+	Stats   *RolloutStats    `json:"stats,omitempty" gorm:"-"`
+	Devices []*RolloutDevice `json:"devices,omitempty" gorm:"-"`
+}
+
+// RolloutDevice adalah progres satu perangkat dalam rollout
+type RolloutDevice struct {
+	RolloutID  uuid.UUID  `json:"rollout_id" gorm:"type:uuid;primary_key"`
+	DeviceID   uuid.UUID  `json:"device_id" gorm:"type:uuid;primary_key;index"`
+	Stage      int        `json:"stage" gorm:"not null"`
+	Status     string     `json:"status" gorm:"not null;size:20;index"`
+	Progress   int        `json:"progress" gorm:"not null;default:0"` // 0-100, dilaporkan perangkat
+	Error      string     `json:"error,omitempty" gorm:"size:500"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type RolloutStats struct {
+	Total      int `json:"total"`
+	Released   int `json:"released"`
+	InProgress int `json:"in_progress"`
+	Succeeded  int `json:"succeeded"`
+	Failed     int `json:"failed"`
+}
+
+type RolloutRequest struct {
+	FirmwareID       uuid.UUID   `json:"firmware_id" validate:"required"`
+	DeviceIDs        []uuid.UUID `json:"device_ids" validate:"max=1000"`
+	GroupIDs         []uuid.UUID `json:"group_ids" validate:"max=50"`
+	Stages           []int       `json:"stages" validate:"omitempty,max=10,dive,min=1,max=100"` // default [100]
+	FailureThreshold *float64    `json:"failure_threshold" validate:"omitempty,min=0,max=100"`  // default 20
+}
+
+// OTANotification dipublikasikan (retained) ke {topic}/{device_id}/ota saat perangkat dirilis
+type OTANotification struct {
+	RolloutID  uuid.UUID `json:"rollout_id"`
+	FirmwareID uuid.UUID `json:"firmware_id"`
+	Version    string    `json:"version"`
+	URL        string    `json:"url"` // download dengan header X-Device-Token
+	Checksum   string    `json:"checksum"`
+	Size       int64     `json:"size"`
+}
+
+// OTAProgress dikirim perangkat ke {topic}/{device_id}/ota/progress
+type OTAProgress struct {
+	RolloutID uuid.UUID `json:"rollout_id"`
+	Status    string    `json:"status"` // downloading, installing, succeeded, failed
+	Progress  int       `json:"progress"`
+	Error     string    `json:"error,omitempty"`
+}
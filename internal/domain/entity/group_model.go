@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceGroup mengelompokkan perangkat milik satu user (many-to-many lewat tabel device_group_members)
+type DeviceGroup struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_device_groups_user_name"`
+	Name        string    `json:"name" gorm:"not null;size:100;uniqueIndex:idx_device_groups_user_name"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Devices []*Device `json:"devices,omitempty" gorm:"many2many:device_group_members"`
+}
+
+type DeviceGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+}
+
+type GroupMembersRequest struct {
+	DeviceIDs []uuid.UUID `json:"device_ids" validate:"required,min=1,max=500"`
+}
+
+const (
+	GroupSeriesFleet  = "fleet"
+	GroupSeriesDevice = "device"
+)
+
+// GroupSeries adalah time-series satu group: Points berisi agregat seluruh perangkat (mode fleet),
+// Devices berisi series per perangkat (mode device)
+type GroupSeries struct {
+	GroupID uuid.UUID           `json:"group_id"`
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	Every   string              `json:"every"`
+	Fn      string              `json:"fn"`
+	Mode    string              `json:"mode"`
+	Metrics []string            `json:"metrics,omitempty"`
+	Points  []*MonitoringData   `json:"points,omitempty"`
+	Devices []*MonitoringSeries `json:"devices,omitempty"`
+}
+
+// DeviceMeta adalah metadata perangkat yang ikut ditulis sebagai tag InfluxDB pada setiap titik telemetry
+type DeviceMeta struct {
+	Type     string            `json:"type,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	GroupIDs []uuid.UUID       `json:"group_ids,omitempty"`
+}
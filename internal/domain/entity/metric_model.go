@@ -0,0 +1,161 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MetricTypeNumber = "number"
+	MetricTypeString = "string"
+	MetricTypeBool   = "bool"
+)
+
+const (
+	// MaxMetricFields membatasi jumlah metric tambahan per titik telemetry
+	MaxMetricFields = 64
+	// maxMetricStringLen membatasi panjang nilai metric bertipe string
+	maxMetricStringLen = 256
+)
+
+// nama metric menjadi nama field InfluxDB dan ikut disisipkan ke query Flux, sehingga dibatasi ketat
+var metricNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// MetricDefinition adalah entri katalog metric per tipe perangkat. Tipe metric dengan nama yang sama
+// harus seragam lintas tipe perangkat karena InfluxDB menyimpan tipe field per measurement.
+type MetricDefinition struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DeviceType  string    `json:"device_type" gorm:"not null;size:50;uniqueIndex:idx_metric_definitions_type_name"`
+	Name        string    `json:"name" gorm:"not null;size:63;uniqueIndex:idx_metric_definitions_type_name;index"`
+	Type        string    `json:"type" gorm:"not null;size:10;default:number"`
+	Unit        string    `json:"unit" gorm:"size:20"`
+	Min         *float64  `json:"min,omitempty"`
+	Max         *float64  `json:"max,omitempty"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Builtin menandai empat metric bawaan yang selalu tersedia dan tidak tersimpan di database
+	Builtin bool `json:"builtin" gorm:"-"`
+}
+
+type MetricDefinitionRequest struct {
+	DeviceType  string   `json:"device_type" validate:"required,oneof=raspberry_pi mini_pc"`
+	Name        string   `json:"name" validate:"required,max=63"`
+	Type        string   `json:"type" validate:"omitempty,oneof=number string bool"`
+	Unit        string   `json:"unit" validate:"omitempty,max=20"`
+	Min         *float64 `json:"min"`
+	Max         *float64 `json:"max"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+}
+
+// MetricDefinitionUpdateRequest hanya mengubah atribut deskriptif, nama dan tipe tidak bisa diubah
+// karena sudah menjadi field di data InfluxDB
+type MetricDefinitionUpdateRequest struct {
+	Unit        string   `json:"unit" validate:"omitempty,max=20"`
+	Min         *float64 `json:"min"`
+	Max         *float64 `json:"max"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// DefaultMetrics adalah metric bawaan yang dikirim sebagai field tetap MonitoringData
+var DefaultMetrics = []MetricDefinition{
+	{Name: "cpu_usage", Type: MetricTypeNumber, Unit: "%", Min: float64Ptr(0), Max: float64Ptr(100), Description: "CPU usage", Builtin: true},
+	{Name: "memory_usage", Type: MetricTypeNumber, Unit: "%", Min: float64Ptr(0), Max: float64Ptr(100), Description: "Memory usage", Builtin: true},
+	{Name: "disk_usage", Type: MetricTypeNumber, Unit: "%", Min: float64Ptr(0), Max: float64Ptr(100), Description: "Disk usage", Builtin: true},
+	{Name: "temperature", Type: MetricTypeNumber, Unit: "°C", Description: "Temperature", Builtin: true},
+}
+
+// DefaultMetricNames adalah metric yang dipakai query series/stats jika tidak ada metric yang dipilih
+func DefaultMetricNames() []string {
+	names := make([]string, len(DefaultMetrics))
+	for i, def := range DefaultMetrics {
+		names[i] = def.Name
+	}
+	return names
+}
+
+func IsDefaultMetric(name string) bool {
+	for _, def := range DefaultMetrics {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidMetricName memeriksa format nama metric dan menolak nama yang bentrok dengan kolom/tag InfluxDB
+func ValidMetricName(name string) bool {
+	if !metricNamePattern.MatchString(name) {
+		return false
+	}
+	switch name {
+	case "device_id", "timestamp", "fields", "result", "table":
+		return false
+	}
+	return !strings.HasPrefix(name, "tag_") && !strings.HasPrefix(name, "group_")
+}
+
+// Normalize memeriksa tipe dan rentang nilai, lalu mengembalikan nilai dalam bentuk yang ditulis
+// ke InfluxDB (angka selalu float64 agar tipe field tidak berubah antar titik)
+func (d *MetricDefinition) Normalize(value interface{}) (interface{}, error) {
+	switch d.Type {
+	case MetricTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("metric %s harus bertipe string", d.Name)
+		}
+		if len(s) > maxMetricStringLen {
+			return nil, fmt.Errorf("metric %s melebihi %d karakter", d.Name, maxMetricStringLen)
+		}
+		return s, nil
+	case MetricTypeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("metric %s harus bertipe bool", d.Name)
+		}
+		return b, nil
+	}
+
+	var v float64
+	switch n := value.(type) {
+	case float64:
+		v = n
+	case float32:
+		v = float64(n)
+	case int:
+		v = float64(n)
+	case int64:
+		v = float64(n)
+	default:
+		return nil, fmt.Errorf("metric %s harus bertipe number", d.Name)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("metric %s bukan angka yang valid", d.Name)
+	}
+	if d.Min != nil && v < *d.Min {
+		return nil, fmt.Errorf("metric %s di bawah batas minimum %g", d.Name, *d.Min)
+	}
+	if d.Max != nil && v > *d.Max {
+		return nil, fmt.Errorf("metric %s di atas batas maksimum %g", d.Name, *d.Max)
+	}
+	return v, nil
+}
+
+// MetricStats adalah statistik satu metric dalam rentang waktu query
+type MetricStats struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	P95   float64 `json:"p95"`
+	Count int     `json:"count"`
+}
@@ -13,6 +13,29 @@ type MonitoringData struct {
 	DiskUsage   float64   `json:"disk_usage"`
 	Temperature float64   `json:"temperature"`
 	Timestamp   time.Time `json:"timestamp"`
+
+	// Fields berisi metric tambahan di luar empat metric bawaan (number, string atau bool),
+	// nama dan tipenya harus terdaftar di katalog metric tipe perangkat
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// Meta diisi usecase sebelum ditulis ke InfluxDB, tidak ikut di payload JSON
+	Meta *DeviceMeta `json:"-"`
+}
+
+// Metric mengembalikan nilai metric berdasarkan nama field JSON-nya, atau metric numerik di Fields
+func (m *MonitoringData) Metric(name string) (float64, bool) {
+	switch name {
+	case "cpu_usage":
+		return m.CPUUsage, true
+	case "memory_usage":
+		return m.MemoryUsage, true
+	case "disk_usage":
+		return m.DiskUsage, true
+	case "temperature":
+		return m.Temperature, true
+	}
+	value, ok := m.Fields[name].(float64)
+	return value, ok
 }
 
 type MonitoringRequest struct {
@@ -44,8 +67,23 @@ type MonitoringQueryParams struct {
 	Limit     int       `query:"limit"`
 }
 
+// MonitoringSeries adalah hasil query time-series yang sudah di-downsample dengan aggregateWindow
+type MonitoringSeries struct {
+	DeviceID uuid.UUID         `json:"device_id"`
+	Start    time.Time         `json:"start"`
+	End      time.Time         `json:"end"`
+	Every    string            `json:"every"`
+	Fn       string            `json:"fn"`
+	Metrics  []string          `json:"metrics,omitempty"`
+	Points   []*MonitoringData `json:"points"`
+}
+
 type MonitoringStats struct {
 	DeviceID   uuid.UUID `json:"device_id"`
+	MinCPU     float64   `json:"min_cpu"`
+	MinMemory  float64   `json:"min_memory"`
+	MinDisk    float64   `json:"min_disk"`
+	MinTemp    float64   `json:"min_temperature"`
 	AvgCPU     float64   `json:"avg_cpu"`
 	AvgMemory  float64   `json:"avg_memory"`
 	AvgDisk    float64   `json:"avg_disk"`
@@ -54,6 +92,25 @@ type MonitoringStats struct {
 	MaxMemory  float64   `json:"max_memory"`
 	MaxDisk    float64   `json:"max_disk"`
 	MaxTemp    float64   `json:"max_temperature"`
+	P95CPU     float64   `json:"p95_cpu"`
+	P95Memory  float64   `json:"p95_memory"`
+	P95Disk    float64   `json:"p95_disk"`
+	P95Temp    float64   `json:"p95_temperature"`
 	DataPoints int       `json:"data_points"`
 	Period     string    `json:"period"`
+
+	// Metrics berisi statistik setiap metric yang diminta, termasuk metric bawaan
+	Metrics map[string]*MetricStats `json:"metrics,omitempty"`
+}
+
+// SpoolStats adalah kondisi spool telemetry di disk yang menampung titik saat InfluxDB tidak tersedia
+type SpoolStats struct {
+	Enabled        bool       `json:"enabled"`
+	Degraded       bool       `json:"degraded"`
+	Segments       int        `json:"segments"`
+	Records        int        `json:"records"`
+	Bytes          int64      `json:"bytes"`
+	MaxBytes       int64      `json:"max_bytes"`
+	DroppedRecords int64      `json:"dropped_records"`
+	OldestAt       *time.Time `json:"oldest_at,omitempty"`
 }
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationChannel struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	URL       string    `json:"url" gorm:"not null;size:500"`
+	Secret    string    `json:"-" gorm:"not null;size:200"`
+	Events    []string  `json:"events" gorm:"type:jsonb;serializer:json"` // kosong berarti semua event
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type NotificationDelivery struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ChannelID  uuid.UUID `json:"channel_id" gorm:"type:uuid;not null;index"`
+	EventID    uuid.UUID `json:"event_id" gorm:"type:uuid;not null;index"`
+	EventType  string    `json:"event_type" gorm:"not null;size:50"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty" gorm:"size:500"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+type NotificationChannelRequest struct {
+	Name    string   `json:"name" validate:"required,max=100"`
+	URL     string   `json:"url" validate:"required,url,max=500"`
+	Secret  string   `json:"secret" validate:"required,min=16,max=200"`
+	Events  []string `json:"events" validate:"dive,oneof=device.online device.offline alert.firing alert.resolved firmware.rollout_halted firmware.rollout_completed security.token_reuse"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// Accepts reports whether the channel subscribes to eventType.
+func (c *NotificationChannel) Accepts(eventType string) bool {
+	if eventType == EventTest || len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
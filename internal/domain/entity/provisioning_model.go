@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProvisionStatusPending = "pending"
+	ProvisionStatusClaimed = "claimed"
+)
+
+// ProvisionRequest dikirim perangkat saat boot pertama lewat HTTP atau MQTT bootstrap topic.
+// ProvisionID boleh diisi perangkat (wajib untuk MQTT) dan dipakai sebagai topic balasan.
+// Topic balasan bisa dibaca client broker lain, sehingga lewat MQTT perangkat wajib mengirim
+// PublicKey (X25519, base64) dan semua balasan dienkripsi ke key tersebut.
+type ProvisionRequest struct {
+	ProvisionID string `json:"provision_id" validate:"omitempty,hexadecimal,min=16,max=64"`
+	MacAddress  string `json:"mac_address" validate:"required,mac"`
+	Type        string `json:"type" validate:"required,oneof=raspberry_pi mini_pc"`
+	Hostname    string `json:"hostname" validate:"required,max=100"`
+	IPAddress   string `json:"ip_address" validate:"omitempty,ip"`
+	PublicKey   string `json:"public_key" validate:"omitempty,base64,max=64"`
+
+	// SourceIP adalah alamat pengirim request HTTP, dipakai membatasi jumlah provisioning per IP
+	SourceIP string `json:"-"`
+}
+
+// SealedMessage adalah balasan provisioning MQTT yang dienkripsi ke PublicKey perangkat
+// (lihat utils.SealToPublicKey), isinya JSON ProvisionTicket atau DeviceCredential
+type SealedMessage struct {
+	ProvisionID string `json:"provision_id"`
+	Sealed      string `json:"sealed"`
+}
+
+// ProvisionTicket dikembalikan ke perangkat, ClaimCode ditampilkan perangkat (layar/log) untuk diketik user
+type ProvisionTicket struct {
+	ProvisionID string    `json:"provision_id"`
+	ClaimCode   string    `json:"claim_code"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PendingProvision adalah state provisioning yang disimpan sementara di Redis
+type PendingProvision struct {
+	ProvisionID string     `json:"provision_id"`
+	ClaimCode   string     `json:"claim_code"`
+	MacAddress  string     `json:"mac_address"`
+	Type        string     `json:"type"`
+	Hostname    string     `json:"hostname"`
+	IPAddress   string     `json:"ip_address"`
+	PublicKey   string     `json:"public_key,omitempty"`
+	Status      string     `json:"status"`
+	DeviceID    *uuid.UUID `json:"device_id,omitempty"`
+	Token       string     `json:"token,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// ProvisionStatus adalah hasil polling perangkat, Credential hanya terisi sekali setelah diklaim.
+// Jika perangkat mengirim PublicKey, kredensial hanya dikirim terenkripsi di SealedCredential.
+type ProvisionStatus struct {
+	Status           string            `json:"status"`
+	Credential       *DeviceCredential `json:"credential,omitempty"`
+	SealedCredential string            `json:"sealed_credential,omitempty"`
+}
+
+// ClaimRequest dikirim user untuk mengklaim perangkat, Name default ke hostname perangkat
+type ClaimRequest struct {
+	ClaimCode string `json:"claim_code" validate:"required,min=6,max=12"`
+	Name      string `json:"name" validate:"omitempty,max=100"`
+	Location  string `json:"location" validate:"omitempty,max=200"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Requester adalah identitas pemanggil yang dipakai usecase untuk membatasi data per tenant.
+type Requester struct {
+	UserID uuid.UUID
+	Role   string
+
+	// DeviceIDs membatasi akses ke perangkat tertentu (API key), kosong berarti tidak dibatasi
+	DeviceIDs []uuid.UUID
+}
+
+func (r *Requester) IsAdmin() bool {
+	return r.Role == RoleAdmin
+}
+
+// CanAccess reports whether the requester may see resources owned by ownerID.
+func (r *Requester) CanAccess(ownerID uuid.UUID) bool {
+	return r.IsAdmin() || r.UserID == ownerID
+}
+
+// CanAccessDevice menggabungkan cek kepemilikan dengan batasan perangkat dari API key.
+func (r *Requester) CanAccessDevice(device *Device) bool {
+	if !r.CanAccess(device.UserID) {
+		return false
+	}
+	if len(r.DeviceIDs) == 0 {
+		return true
+	}
+	for _, id := range r.DeviceIDs {
+		if id == device.ID {
+			return true
+		}
+	}
+	return false
+}
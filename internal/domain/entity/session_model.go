@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session adalah satu sesi login (satu perangkat/browser) yang disimpan di Redis.
+// Satu sesi juga merupakan satu family refresh token: setiap refresh menerbitkan
+// token baru dengan SessionID yang sama dan Generation yang bertambah.
+type Session struct {
+	ID              string    `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	RefreshJTI      string    `json:"refresh_jti"`
+	Generation      int       `json:"generation"`
+	AccessJTI       string    `json:"access_jti"`
+	AccessExpiresAt time.Time `json:"access_expires_at"`
+	UserAgent       string    `json:"user_agent"`
+	IPAddress       string    `json:"ip_address"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastUsedAt      time.Time `json:"last_used_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// SessionInfo adalah tampilan sesi untuk GET /auth/sessions tanpa data token
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionMeta adalah informasi client yang dicatat saat login atau refresh
+type SessionMeta struct {
+	UserAgent string
+	IPAddress string
+}
+
+// TokenReuseEvent adalah data event security.token_reuse
+type TokenReuseEvent struct {
+	SessionID  string `json:"session_id"`
+	TokenID    string `json:"token_id"`
+	Generation int    `json:"generation"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceShadow adalah dokumen konfigurasi perangkat. Desired diubah lewat REST, Reported
+// dikirim perangkat. Version bertambah setiap dokumen berubah dan dipakai untuk optimistic locking.
+type DeviceShadow struct {
+	DeviceID          uuid.UUID              `json:"device_id" gorm:"type:uuid;primary_key"`
+	Desired           map[string]interface{} `json:"desired" gorm:"type:jsonb;serializer:json"`
+	Reported          map[string]interface{} `json:"reported" gorm:"type:jsonb;serializer:json"`
+	Version           int64                  `json:"version" gorm:"not null;default:0"`
+	DesiredVersion    int64                  `json:"desired_version" gorm:"not null;default:0"`
+	ReportedVersion   int64                  `json:"reported_version" gorm:"not null;default:0"`
+	DesiredUpdatedAt  *time.Time             `json:"desired_updated_at,omitempty"`
+	ReportedUpdatedAt *time.Time             `json:"reported_updated_at,omitempty"`
+	UpdatedAt         time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Delta dihitung saat dibaca, tidak disimpan
+	Delta map[string]interface{} `json:"delta,omitempty" gorm:"-"`
+}
+
+// ComputeDelta returns the desired keys whose value differs from reported, recursing into nested objects.
+func (s *DeviceShadow) ComputeDelta() map[string]interface{} {
+	return stateDelta(s.Desired, s.Reported)
+}
+
+func stateDelta(desired, reported map[string]interface{}) map[string]interface{} {
+	delta := make(map[string]interface{})
+	for key, want := range desired {
+		have, ok := reported[key]
+		if !ok {
+			delta[key] = want
+			continue
+		}
+		wantMap, wantIsMap := want.(map[string]interface{})
+		haveMap, haveIsMap := have.(map[string]interface{})
+		if wantIsMap && haveIsMap {
+			if nested := stateDelta(wantMap, haveMap); len(nested) > 0 {
+				delta[key] = nested
+			}
+			continue
+		}
+		if !reflect.DeepEqual(want, have) {
+			delta[key] = want
+		}
+	}
+	return delta
+}
+
+// ShadowUpdateRequest adalah JSON merge patch untuk satu bagian shadow, nilai null menghapus key
+type ShadowUpdateRequest struct {
+	State   map[string]interface{} `json:"state" validate:"required"`
+	Version *int64                 `json:"version"` // jika diisi harus sama dengan version saat ini
+}
+
+// ShadowDelta dipublikasikan (retained) ke {topic}/{device_id}/shadow/delta setiap desired ≠ reported
+type ShadowDelta struct {
+	Version   int64                  `json:"version"`
+	State     map[string]interface{} `json:"state"`
+	Timestamp time.Time              `json:"timestamp"`
+}
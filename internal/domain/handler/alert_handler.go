@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AlertHandler struct {
+	alertUsecase iface.AlertUseCase
+	validate     *validator.Validate
+}
+
+func NewAlertHandler(au iface.AlertUseCase, validate *validator.Validate) *AlertHandler {
+	return &AlertHandler{
+		alertUsecase: au,
+		validate:     validate,
+	}
+}
+
+// POST /alerts/rules
+func (h *AlertHandler) CreateRule(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.AlertRuleRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	rule, err := h.alertUsecase.CreateRule(c.Context(), requester, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": rule,
+	})
+}
+
+// GET /alerts/rules
+func (h *AlertHandler) ListRules(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	rules, err := h.alertUsecase.ListRules(c.Context(), requester)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rules,
+	})
+}
+
+// GET /alerts/rules/:id
+func (h *AlertHandler) GetRule(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule id"})
+	}
+
+	rule, err := h.alertUsecase.GetRule(c.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rule,
+	})
+}
+
+// PUT /alerts/rules/:id
+func (h *AlertHandler) UpdateRule(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule id"})
+	}
+
+	req := new(entity.AlertRuleRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	rule, err := h.alertUsecase.UpdateRule(c.Context(), requester, id, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rule,
+	})
+}
+
+// DELETE /alerts/rules/:id
+func (h *AlertHandler) DeleteRule(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule id"})
+	}
+
+	if err := h.alertUsecase.DeleteRule(c.Context(), requester, id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Alert rule deleted successfully",
+	})
+}
+
+// GET /alerts?state=active|pending|firing|resolved&device_id=...&limit=50&offset=0
+func (h *AlertHandler) ListAlerts(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	query := new(entity.AlertQuery)
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid query parameters"})
+	}
+
+	if err := h.validate.Struct(query); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	alerts, err := h.alertUsecase.ListAlerts(c.Context(), requester, query)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": alerts,
+	})
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyUsecase iface.APIKeyUseCase
+	validate      *validator.Validate
+}
+
+func NewAPIKeyHandler(au iface.APIKeyUseCase, validate *validator.Validate) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUsecase: au,
+		validate:      validate,
+	}
+}
+
+// POST /api-keys
+// Plaintext key hanya dikembalikan sekali di respons ini
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.APIKeyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	key, err := h.apiKeyUsecase.Create(c.Context(), requester, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": key,
+	})
+}
+
+// GET /api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	keys, err := h.apiKeyUsecase.List(c.Context(), requester)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": keys,
+	})
+}
+
+// DELETE /api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid api key id"})
+	}
+
+	if err := h.apiKeyUsecase.Revoke(c.Context(), requester, id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API key revoked successfully",
+	})
+}
@@ -3,20 +3,24 @@ package handler
 import (
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/jwt"
 	"monitoring/pkg/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
 	authUsecase iface.AuthUsecase
+	jwtService  jwt.JwtService
 	validate    *validator.Validate
 }
 
-func NewAuthHandler(au iface.AuthUsecase, validate *validator.Validate) *AuthHandler {
+func NewAuthHandler(au iface.AuthUsecase, jwtService jwt.JwtService, validate *validator.Validate) *AuthHandler {
 	return &AuthHandler{
 		authUsecase: au,
+		jwtService:  jwtService,
 		validate:    validate,
 	}
 }
@@ -50,7 +54,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
-	accessToken, refreshToken, err := h.authUsecase.Login(c.Context(), req.Username, req.Password)
+	accessToken, refreshToken, err := h.authUsecase.Login(c.Context(), req.Username, req.Password, sessionMeta(c))
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
@@ -67,7 +71,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
-	newAccessToken, newRefreshToken, err := h.authUsecase.RefreshToken(c.Context(), req.RefreshToken)
+	newAccessToken, newRefreshToken, err := h.authUsecase.RefreshToken(c.Context(), req.RefreshToken, sessionMeta(c))
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
@@ -77,3 +81,94 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		"refresh_token": newRefreshToken,
 	})
 }
+
+// POST /auth/logout
+// Mencabut sesi dari access token yang sedang dipakai
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	claims, userID, ok := sessionFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	if err := h.authUsecase.Logout(c.Context(), userID, claims.SessionID); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+// POST /auth/logout-all
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	_, userID, ok := sessionFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	if err := h.authUsecase.LogoutAll(c.Context(), userID); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "All sessions logged out successfully",
+	})
+}
+
+// GET /auth/sessions
+func (h *AuthHandler) ListSessions(c *fiber.Ctx) error {
+	claims, userID, ok := sessionFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	sessions, err := h.authUsecase.ListSessions(c.Context(), userID, claims.SessionID)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": sessions,
+	})
+}
+
+// DELETE /auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	_, userID, ok := sessionFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	if err := h.authUsecase.RevokeSession(c.Context(), userID, c.Params("id")); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Session revoked successfully",
+	})
+}
+
+// GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(h.jwtService.JWKS())
+}
+
+func sessionMeta(c *fiber.Ctx) *entity.SessionMeta {
+	return &entity.SessionMeta{
+		UserAgent: c.Get("User-Agent"),
+		IPAddress: c.IP(),
+	}
+}
+
+func sessionFromCtx(c *fiber.Ctx) (*jwt.Claims, uuid.UUID, bool) {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, uuid.Nil, false
+	}
+	return claims, userID, true
+}
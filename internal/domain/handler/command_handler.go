@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CommandHandler struct {
+	commandUsecase iface.CommandUseCase
+	validate       *validator.Validate
+}
+
+func NewCommandHandler(cu iface.CommandUseCase, validate *validator.Validate) *CommandHandler {
+	return &CommandHandler{
+		commandUsecase: cu,
+		validate:       validate,
+	}
+}
+
+// POST /devices/:id/commands
+// Perintah dikirim asinkron, status akhirnya dibaca lewat GET /devices/:id/commands/:command_id
+func (h *CommandHandler) SendCommand(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	req := new(entity.CommandRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	command, err := h.commandUsecase.Send(c.Context(), requester, deviceID, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"data": command,
+	})
+}
+
+// GET /devices/:id/commands?status=pending&limit=50&offset=0
+func (h *CommandHandler) ListCommands(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	query := new(entity.CommandQuery)
+	if err := c.QueryParser(query); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(query); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+	query.DeviceID = deviceID
+
+	commands, err := h.commandUsecase.List(c.Context(), requester, query)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": commands,
+	})
+}
+
+// GET /devices/:id/commands/:command_id
+func (h *CommandHandler) GetCommand(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	id, err := uuid.Parse(c.Params("command_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid command id"})
+	}
+
+	command, err := h.commandUsecase.Get(c.Context(), requester, deviceID, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": command,
+	})
+}
@@ -40,35 +40,150 @@ func (d *deviceHandler) CreateDevice(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
 	}
 
-	err := d.deviceUsecase.Create(ctx.Context(), &entity.Device{
+	device := &entity.Device{
 		Name:       reqDevice.Name,
 		Type:       reqDevice.Type,
 		MacAddress: reqDevice.MacAddress,
 		IPAddress:  reqDevice.IPAddress,
 		Location:   reqDevice.Location,
+		Tags:       reqDevice.Tags,
 		UserID:     uuid.MustParse(claims.UserID),
-	})
+	}
+	token, err := d.deviceUsecase.Create(ctx.Context(), device)
 
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return fiber.NewError(errorStatus(err), err.Error())
 	}
 	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "Device registered successfully",
+		"data":    &entity.DeviceCredential{Device: device, Token: token},
+	})
+}
+
+// POST /devices/:id/token
+// Buat ulang token perangkat, token lama langsung tidak berlaku
+func (d *deviceHandler) RotateDeviceToken(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	credential, err := d.deviceUsecase.RotateToken(ctx.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": credential,
 	})
 }
 
+// GET /devices?limit=20&offset=0&type=raspberry_pi&location=gudang&is_online=true&sort_by=name&order=asc
+// GET /devices?limit=20&cursor=<next_cursor>
+func (d *deviceHandler) ListDevices(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	query := new(entity.DeviceListQuery)
+	if err := ctx.QueryParser(query); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid query parameters"})
+	}
+
+	if err := d.validate.Struct(query); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	devices, err := d.deviceUsecase.ListOwned(ctx.Context(), requester, query)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(devices)
+}
+
+// GET /devices/:id
 func (d *deviceHandler) GetDevice(ctx *fiber.Ctx) error {
-	_, ok := ctx.Locals("user").(*jwt.Claims)
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	device, err := d.deviceUsecase.GetOwned(ctx.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": device,
+	})
+}
+
+// PATCH /devices/:id
+func (d *deviceHandler) UpdateDevice(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
 	if !ok {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
 	}
 
-	devices, err := d.deviceUsecase.List(ctx.Context(), 10, 0)
+	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	var req entity.DeviceUpdateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := d.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	device, err := d.deviceUsecase.UpdateOwned(ctx.Context(), requester, id, &req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": device,
+	})
+}
+
+// DELETE /devices/:id
+func (d *deviceHandler) DeleteDevice(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	if err := d.deviceUsecase.DeleteOwned(ctx.Context(), requester, id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"data": devices,
+		"message": "Device deleted successfully",
 	})
 }
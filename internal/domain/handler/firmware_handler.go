@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FirmwareHandler struct {
+	firmwareUsecase iface.FirmwareUseCase
+	validate        *validator.Validate
+}
+
+func NewFirmwareHandler(fu iface.FirmwareUseCase, validate *validator.Validate) *FirmwareHandler {
+	return &FirmwareHandler{
+		firmwareUsecase: fu,
+		validate:        validate,
+	}
+}
+
+// POST /firmware (multipart/form-data)
+// Field: file, version, device_type, checksum (opsional, SHA-256 hex), notes
+func (h *FirmwareHandler) UploadFirmware(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.FirmwareRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing firmware file"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	defer file.Close()
+
+	firmware, err := h.firmwareUsecase.Upload(c.Context(), requester, req, file)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": firmware,
+	})
+}
+
+// GET /firmware
+func (h *FirmwareHandler) ListFirmware(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	firmwares, err := h.firmwareUsecase.List(c.Context(), requester)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": firmwares,
+	})
+}
+
+// GET /firmware/:id
+func (h *FirmwareHandler) GetFirmware(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid firmware id"})
+	}
+
+	firmware, err := h.firmwareUsecase.Get(c.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": firmware,
+	})
+}
+
+// DELETE /firmware/:id
+func (h *FirmwareHandler) DeleteFirmware(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid firmware id"})
+	}
+
+	if err := h.firmwareUsecase.Delete(c.Context(), requester, id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Firmware deleted successfully",
+	})
+}
+
+// GET /ota/firmware/:id
+// Download artifact oleh perangkat (header X-Device-Token), URL-nya dikirim lewat notifikasi OTA
+func (h *FirmwareHandler) DownloadFirmware(c *fiber.Ctx) error {
+	device, ok := c.Locals("device").(*entity.Device)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid device data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid firmware id"})
+	}
+
+	firmware, artifact, err := h.firmwareUsecase.OpenArtifact(c.Context(), device, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/octet-stream")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.bin"`, firmware.DeviceType, firmware.Version))
+	c.Set("X-Checksum-SHA256", firmware.Checksum)
+	// fasthttp menutup artifact setelah stream selesai dikirim
+	return c.SendStream(artifact, int(firmware.Size))
+}
+
+// POST /firmware/rollouts
+func (h *FirmwareHandler) CreateRollout(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.RolloutRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	rollout, err := h.firmwareUsecase.CreateRollout(c.Context(), requester, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": rollout,
+	})
+}
+
+// GET /firmware/rollouts
+func (h *FirmwareHandler) ListRollouts(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	rollouts, err := h.firmwareUsecase.ListRollouts(c.Context(), requester)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rollouts,
+	})
+}
+
+// GET /firmware/rollouts/:id
+// Termasuk ringkasan dan progres per perangkat
+func (h *FirmwareHandler) GetRollout(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rollout id"})
+	}
+
+	rollout, err := h.firmwareUsecase.GetRollout(c.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rollout,
+	})
+}
+
+// POST /firmware/rollouts/:id/halt
+func (h *FirmwareHandler) HaltRollout(c *fiber.Ctx) error {
+	return h.rolloutAction(c, h.firmwareUsecase.HaltRollout)
+}
+
+// POST /firmware/rollouts/:id/resume
+func (h *FirmwareHandler) ResumeRollout(c *fiber.Ctx) error {
+	return h.rolloutAction(c, h.firmwareUsecase.ResumeRollout)
+}
+
+// POST /firmware/rollouts/:id/cancel
+func (h *FirmwareHandler) CancelRollout(c *fiber.Ctx) error {
+	return h.rolloutAction(c, h.firmwareUsecase.CancelRollout)
+}
+
+func (h *FirmwareHandler) rolloutAction(c *fiber.Ctx, action func(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error)) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rollout id"})
+	}
+
+	rollout, err := action(c.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rollout,
+	})
+}
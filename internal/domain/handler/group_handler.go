@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type GroupHandler struct {
+	groupUsecase iface.GroupUseCase
+	validate     *validator.Validate
+}
+
+func NewGroupHandler(gu iface.GroupUseCase, validate *validator.Validate) *GroupHandler {
+	return &GroupHandler{
+		groupUsecase: gu,
+		validate:     validate,
+	}
+}
+
+// POST /groups
+func (h *GroupHandler) CreateGroup(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.DeviceGroupRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	group, err := h.groupUsecase.Create(c.Context(), requester, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": group,
+	})
+}
+
+// GET /groups
+func (h *GroupHandler) ListGroups(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	groups, err := h.groupUsecase.List(c.Context(), requester)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": groups,
+	})
+}
+
+// GET /groups/:id
+func (h *GroupHandler) GetGroup(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	group, err := h.groupUsecase.Get(c.Context(), requester, id)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": group,
+	})
+}
+
+// PUT /groups/:id
+func (h *GroupHandler) UpdateGroup(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	req := new(entity.DeviceGroupRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	group, err := h.groupUsecase.Update(c.Context(), requester, id, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": group,
+	})
+}
+
+// DELETE /groups/:id
+// Menghapus group tidak menghapus perangkat anggotanya
+func (h *GroupHandler) DeleteGroup(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	if err := h.groupUsecase.Delete(c.Context(), requester, id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Device group deleted successfully",
+	})
+}
+
+// POST /groups/:id/devices
+func (h *GroupHandler) AddDevices(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	req := new(entity.GroupMembersRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	group, err := h.groupUsecase.AddDevices(c.Context(), requester, id, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": group,
+	})
+}
+
+// DELETE /groups/:id/devices/:device_id
+func (h *GroupHandler) RemoveDevice(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("device_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device_id"})
+	}
+
+	if err := h.groupUsecase.RemoveDevice(c.Context(), requester, id, deviceID); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Device removed from group successfully",
+	})
+}
+
+// GET /groups/:id/telemetry/series?start=...&end=...&every=5m&fn=mean&mode=fleet|device&metrics=cpu_usage,load_1m
+// Ambil time-series seluruh perangkat di group, default 24 jam terakhir
+func (h *GroupHandler) GetGroupSeries(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group id"})
+	}
+
+	startTime, endTime, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var every time.Duration
+	if everyStr := c.Query("every"); everyStr != "" {
+		every, err = time.ParseDuration(everyStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid every"})
+		}
+	}
+
+	series, err := h.groupUsecase.GetSeries(c.Context(), requester, id, startTime, endTime, every, c.Query("fn"), c.Query("mode"), parseMetrics(c))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(series)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"monitoring/internal/domain/entity"
+	"monitoring/pkg/jwt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// requesterFromCtx membangun entity.Requester dari claims yang disimpan JWTMiddleware (JWT atau API key)
+func requesterFromCtx(ctx *fiber.Ctx) (*entity.Requester, bool) {
+	claims, ok := ctx.Locals("user").(*jwt.Claims)
+	if !ok {
+		return nil, false
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, false
+	}
+	requester := &entity.Requester{UserID: userID, Role: claims.Role}
+	if key, ok := ctx.Locals("api_key").(*entity.APIKey); ok {
+		requester.DeviceIDs = key.DeviceIDs
+	}
+	return requester, true
+}
+
+// errorStatus memetakan error domain ke HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrDeviceNotFound), errors.Is(err, entity.ErrNoTelemetry),
+		errors.Is(err, entity.ErrRuleNotFound), errors.Is(err, entity.ErrChannelNotFound),
+		errors.Is(err, entity.ErrSessionNotFound), errors.Is(err, entity.ErrAPIKeyNotFound),
+		errors.Is(err, entity.ErrProvisionNotFound), errors.Is(err, entity.ErrGroupNotFound),
+		errors.Is(err, entity.ErrCommandNotFound), errors.Is(err, entity.ErrFirmwareNotFound),
+		errors.Is(err, entity.ErrRolloutNotFound), errors.Is(err, entity.ErrMetricNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, entity.ErrDeviceExists), errors.Is(err, entity.ErrGroupExists),
+		errors.Is(err, entity.ErrShadowConflict), errors.Is(err, entity.ErrFirmwareExists),
+		errors.Is(err, entity.ErrMetricExists), errors.Is(err, entity.ErrProvisionConflict):
+		return fiber.StatusConflict
+	case errors.Is(err, entity.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, entity.ErrInvalidCursor), errors.Is(err, entity.ErrInvalidQuery),
+		errors.Is(err, entity.ErrInvalidRequest):
+		return fiber.StatusBadRequest
+	case errors.Is(err, entity.ErrTooManyRequests):
+		return fiber.StatusTooManyRequests
+	case errors.Is(err, entity.ErrTelemetryWrite):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+	"sort"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ingestMaxClockSkew adalah batas timestamp perangkat yang boleh berada di depan jam server
+const ingestMaxClockSkew = 5 * time.Minute
+
+type IngestHandler struct {
+	monitoringUsecase iface.MonitoringUseCase
+	deviceUsecase     iface.DeviceUseCase
+	alertUsecase      iface.AlertUseCase
+	validate          *validator.Validate
+}
+
+func NewIngestHandler(mu iface.MonitoringUseCase, du iface.DeviceUseCase, au iface.AlertUseCase, validate *validator.Validate) *IngestHandler {
+	return &IngestHandler{
+		monitoringUsecase: mu,
+		deviceUsecase:     du,
+		alertUsecase:      au,
+		validate:          validate,
+	}
+}
+
+// POST /ingest
+// Header X-Device-Token. Body berupa satu objek reading atau array reading (maksimal 1000).
+// Data ditulis langsung lewat MonitoringUseCase tanpa melewati broker MQTT.
+func (h *IngestHandler) Ingest(ctx *fiber.Ctx) error {
+	device, ok := ctx.Locals("device").(*entity.Device)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid device data"})
+	}
+
+	batch := new(entity.IngestBatch)
+	body := bytes.TrimSpace(ctx.Body())
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &batch.Readings); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON"})
+		}
+	} else {
+		var reading entity.IngestReading
+		if err := json.Unmarshal(body, &reading); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON"})
+		}
+		batch.Readings = []entity.IngestReading{reading}
+	}
+
+	if err := h.validate.Struct(batch); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	now := time.Now()
+	data := make([]*entity.MonitoringData, 0, len(batch.Readings))
+	for _, reading := range batch.Readings {
+		timestamp := now
+		if reading.Timestamp != nil {
+			timestamp = *reading.Timestamp
+		}
+		if timestamp.After(now.Add(ingestMaxClockSkew)) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Timestamp is too far in the future"})
+		}
+		data = append(data, &entity.MonitoringData{
+			DeviceID:    device.ID,
+			CPUUsage:    reading.CPUUsage,
+			MemoryUsage: reading.MemoryUsage,
+			DiskUsage:   reading.DiskUsage,
+			Temperature: reading.Temperature,
+			Fields:      reading.Fields,
+			Timestamp:   timestamp,
+		})
+	}
+
+	// Urutkan agar state machine alert melihat data sesuai urutan waktu
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Timestamp.Before(data[j].Timestamp) })
+
+	// Ditulis blocking dalam satu request: jika gagal tidak ada reading yang tersimpan
+	if err := h.monitoringUsecase.StoreMonitoringBatch(ctx.Context(), data); err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{
+			"error":    err.Error(),
+			"accepted": 0,
+		})
+	}
+
+	// Evaluate mengabaikan reading yang lebih lama dari perubahan state alert terakhir
+	for _, item := range data {
+		if err := h.alertUsecase.Evaluate(ctx.Context(), item); err != nil {
+			log.Printf("Failed to evaluate alert rules: %v", err)
+		}
+	}
+
+	if err := h.deviceUsecase.Heartbeat(ctx.Context(), device.ID); err != nil {
+		log.Printf("Failed to update device heartbeat: %v", err)
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"accepted": len(data),
+	})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type MetricHandler struct {
+	metricUsecase iface.MetricUseCase
+	validate      *validator.Validate
+}
+
+func NewMetricHandler(mu iface.MetricUseCase, validate *validator.Validate) *MetricHandler {
+	return &MetricHandler{
+		metricUsecase: mu,
+		validate:      validate,
+	}
+}
+
+// GET /metrics?device_type=raspberry_pi
+// Katalog metric bawaan dan metric tambahan, device_type kosong berarti semua tipe perangkat
+func (h *MetricHandler) ListMetrics(c *fiber.Ctx) error {
+	metrics, err := h.metricUsecase.List(c.Context(), c.Query("device_type"))
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": metrics,
+	})
+}
+
+// POST /metrics
+func (h *MetricHandler) CreateMetric(c *fiber.Ctx) error {
+	req := new(entity.MetricDefinitionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	metric, err := h.metricUsecase.Create(c.Context(), req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": metric,
+	})
+}
+
+// PUT /metrics/:id
+func (h *MetricHandler) UpdateMetric(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid metric id"})
+	}
+
+	req := new(entity.MetricDefinitionUpdateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	metric, err := h.metricUsecase.Update(c.Context(), id, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": metric,
+	})
+}
+
+// DELETE /metrics/:id
+func (h *MetricHandler) DeleteMetric(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid metric id"})
+	}
+
+	if err := h.metricUsecase.Delete(c.Context(), id); err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Metric definition deleted successfully",
+	})
+}
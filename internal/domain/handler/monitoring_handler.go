@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"fmt"
-	"monitoring/internal/domain/entity"
+	"errors"
 	iface "monitoring/internal/domain/interface"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -13,44 +13,28 @@ import (
 )
 
 type MonitoringHandler struct {
-	usecase  iface.MonitoringUseCase
-	validate *validator.Validate
+	usecase     iface.MonitoringUseCase
+	authUsecase iface.AuthUsecase
+	validate    *validator.Validate
 }
 
-func NewMonitoringHandler(uc iface.MonitoringUseCase, validate *validator.Validate) *MonitoringHandler {
+func NewMonitoringHandler(uc iface.MonitoringUseCase, authUsecase iface.AuthUsecase, validate *validator.Validate) *MonitoringHandler {
 	return &MonitoringHandler{
-		usecase:  uc,
-		validate: validate,
+		usecase:     uc,
+		authUsecase: authUsecase,
+		validate:    validate,
 	}
 }
 
-// POST /monitoring
-// Simpan data monitoring
-func (h *MonitoringHandler) StoreMonitoring(ctx *fiber.Ctx) error {
-	var data entity.MonitoringData
-
-	if err := ctx.BodyParser(&data); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON"})
-	}
-
-	// Validasi struct
-	if err := h.validate.Struct(&data); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	// Simpan data
-	if err := h.usecase.StoreMonitoringData(ctx.Context(), &data); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	return ctx.JSON(fiber.Map{"message": "Data monitoring berhasil disimpan"})
-}
-
 // GET /monitoring/:device_id?start=2023-01-01T00:00:00Z&end=2023-01-02T00:00:00Z&limit=100
 // Ambil data monitoring berdasarkan device_id dan range waktu
 func (h *MonitoringHandler) GetMonitoringByDeviceID(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
 	deviceIDStr := ctx.Params("device_id")
-	fmt.Println(deviceIDStr)
 	deviceID, err := uuid.Parse(deviceIDStr)
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device_id"})
@@ -74,10 +58,146 @@ func (h *MonitoringHandler) GetMonitoringByDeviceID(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid limit"})
 	}
 
-	data, err := h.usecase.GetMonitoringDataByDevice(ctx.Context(), deviceID, startTime, endTime, limit)
+	data, err := h.usecase.GetMonitoringDataByDevice(ctx.Context(), requester, deviceID, startTime, endTime, limit)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return ctx.JSON(data)
 }
+
+// GET /telemetry/device/:device_id/latest
+// Ambil data monitoring terakhir (last known state) dari sebuah perangkat
+func (h *MonitoringHandler) GetLatestMonitoring(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(ctx.Params("device_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device_id"})
+	}
+
+	data, err := h.usecase.GetLatestMonitoringData(ctx.Context(), requester, deviceID)
+	if err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(data)
+}
+
+// GET /telemetry/latest
+// Ambil data monitoring terakhir untuk semua perangkat milik user
+func (h *MonitoringHandler) GetLatestForOwnedDevices(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	data, err := h.usecase.GetLatestForOwnedDevices(ctx.Context(), requester)
+	if err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(fiber.Map{"data": data})
+}
+
+// GET /telemetry/device/:device_id/series?start=2023-01-01T00:00:00Z&end=2023-01-31T00:00:00Z&every=5m&fn=mean&metrics=cpu_usage,load_1m
+// Ambil time-series yang di-downsample, jika every kosong window dipilih otomatis (default 24 jam terakhir).
+// metrics kosong berarti empat metric bawaan.
+func (h *MonitoringHandler) GetMonitoringSeries(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(ctx.Params("device_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device_id"})
+	}
+
+	startTime, endTime, err := parseTimeRange(ctx, 24*time.Hour)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var every time.Duration
+	if everyStr := ctx.Query("every"); everyStr != "" {
+		every, err = time.ParseDuration(everyStr)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid every"})
+		}
+	}
+
+	series, err := h.usecase.GetMonitoringSeries(ctx.Context(), requester, deviceID, startTime, endTime, every, ctx.Query("fn"), parseMetrics(ctx))
+	if err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(series)
+}
+
+// GET /telemetry/device/:device_id/stats?start=2023-01-01T00:00:00Z&end=2023-01-02T00:00:00Z&metrics=cpu_usage,load_1m
+// Ambil statistik min/avg/max/p95 per metric, default 24 jam terakhir dan empat metric bawaan
+func (h *MonitoringHandler) GetMonitoringStats(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(ctx.Params("device_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device_id"})
+	}
+
+	startTime, endTime, err := parseTimeRange(ctx, 24*time.Hour)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	stats, err := h.usecase.GetMonitoringStats(ctx.Context(), requester, deviceID, startTime, endTime, parseMetrics(ctx))
+	if err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(stats)
+}
+
+// GET /system/spool
+// Kondisi spool telemetry di disk (jumlah titik tertahan, ukuran, titik terbuang), hanya untuk admin
+func (h *MonitoringHandler) GetSpoolStats(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"data": h.usecase.GetSpoolStats(ctx.Context())})
+}
+
+// parseTimeRange membaca query start & end (RFC3339). Jika kosong, end = sekarang dan start = end - defaultRange
+func parseTimeRange(ctx *fiber.Ctx, defaultRange time.Duration) (time.Time, time.Time, error) {
+	endTime := time.Now()
+	if endStr := ctx.Query("end"); endStr != "" {
+		t, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid end time")
+		}
+		endTime = t
+	}
+
+	startTime := endTime.Add(-defaultRange)
+	if startStr := ctx.Query("start"); startStr != "" {
+		t, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid start time")
+		}
+		startTime = t
+	}
+
+	return startTime, endTime, nil
+}
+
+// parseMetrics membaca query metrics yang dipisah koma, validasi nama dilakukan usecase
+func parseMetrics(ctx *fiber.Ctx) []string {
+	raw := ctx.Query("metrics")
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
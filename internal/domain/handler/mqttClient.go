@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
 	"monitoring/pkg/db"
 	"monitoring/pkg/utils"
 	"time"
@@ -14,14 +15,16 @@ import (
 )
 
 type MQTTHandler struct {
-	mqtt     *db.MQTTClient
-	validate *validator.Validate
+	mqtt          *db.MQTTClient
+	deviceUsecase iface.DeviceUseCase
+	validate      *validator.Validate
 }
 
-func NewMQTTHandler(m *db.MQTTClient, validate *validator.Validate) *MQTTHandler {
+func NewMQTTHandler(m *db.MQTTClient, deviceUsecase iface.DeviceUseCase, validate *validator.Validate) *MQTTHandler {
 	return &MQTTHandler{
-		mqtt:     m,
-		validate: validate,
+		mqtt:          m,
+		deviceUsecase: deviceUsecase,
+		validate:      validate,
 	}
 }
 
@@ -34,6 +37,20 @@ func (m *MQTTHandler) TriggerMQTT(ctx *fiber.Ctx) error {
 		})
 	}
 
+	deviceID, err := uuid.Parse(id)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device ID"})
+	}
+
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	if _, err := m.deviceUsecase.GetOwned(ctx.Context(), requester, deviceID); err != nil {
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	var payload entity.MonitoringRequest
 	if err := ctx.BodyParser(&payload); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid JSON"})
@@ -47,7 +64,7 @@ func (m *MQTTHandler) TriggerMQTT(ctx *fiber.Ctx) error {
 	}
 
 	sendData := entity.MonitoringData{
-		DeviceID:    uuid.MustParse(id),
+		DeviceID:    deviceID,
 		CPUUsage:    payload.CPUUsage,
 		MemoryUsage: payload.MemoryUsage,
 		DiskUsage:   payload.DiskUsage,
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProvisioningHandler struct {
+	provisioningUsecase iface.ProvisioningUseCase
+	validate            *validator.Validate
+}
+
+func NewProvisioningHandler(pu iface.ProvisioningUseCase, validate *validator.Validate) *ProvisioningHandler {
+	return &ProvisioningHandler{
+		provisioningUsecase: pu,
+		validate:            validate,
+	}
+}
+
+// POST /provision
+// Dipanggil perangkat yang belum terdaftar, mengembalikan claim code untuk diklaim user
+func (h *ProvisioningHandler) RequestProvision(c *fiber.Ctx) error {
+	req := new(entity.ProvisionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+	if req.IPAddress == "" {
+		req.IPAddress = c.IP()
+	}
+	req.SourceIP = c.IP()
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	ticket, err := h.provisioningUsecase.Request(c.Context(), req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": ticket,
+	})
+}
+
+// GET /provision/:id
+// Polling oleh perangkat, 202 selama belum diklaim dan 200 beserta kredensial setelah diklaim
+func (h *ProvisioningHandler) PollProvision(c *fiber.Ctx) error {
+	status, err := h.provisioningUsecase.Poll(c.Context(), c.Params("id"))
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	if status.Status == entity.ProvisionStatusPending {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"data": status,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"data": status,
+	})
+}
+
+// POST /devices/claim
+func (h *ProvisioningHandler) ClaimDevice(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	req := new(entity.ClaimRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	device, err := h.provisioningUsecase.Claim(c.Context(), requester, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": device,
+	})
+}
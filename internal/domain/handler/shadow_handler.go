@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShadowHandler struct {
+	shadowUsecase iface.ShadowUseCase
+	validate      *validator.Validate
+}
+
+func NewShadowHandler(su iface.ShadowUseCase, validate *validator.Validate) *ShadowHandler {
+	return &ShadowHandler{
+		shadowUsecase: su,
+		validate:      validate,
+	}
+}
+
+// GET /devices/:id/shadow
+func (h *ShadowHandler) GetShadow(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	shadow, err := h.shadowUsecase.Get(c.Context(), requester, deviceID)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": shadow,
+	})
+}
+
+// PATCH /devices/:id/shadow/desired
+// Body berupa merge patch {"state": {...}, "version": n}, nilai null menghapus key
+func (h *ShadowHandler) UpdateDesired(c *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device id"})
+	}
+
+	req := new(entity.ShadowUpdateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	shadow, err := h.shadowUsecase.UpdateDesired(c.Context(), requester, deviceID, req)
+	if err != nil {
+		return fiber.NewError(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": shadow,
+	})
+}
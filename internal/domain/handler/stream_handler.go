@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/jwt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	streamKeepAlive = 15 * time.Second
+	// streamAuthInterval adalah jeda pemeriksaan ulang token selama stream terbuka
+	streamAuthInterval = 30 * time.Second
+)
+
+var (
+	errStreamTokenExpired = errors.New("token expired")
+	errStreamTokenRevoked = errors.New("token has been revoked")
+)
+
+// streamAuth menyimpan kredensial pembuka stream. Middleware hanya memeriksa token saat koneksi dibuka,
+// sehingga stream memeriksa ulang masa berlaku dan denylist secara berkala.
+type streamAuth struct {
+	claims      *jwt.Claims
+	apiKey      *entity.APIKey
+	authUsecase iface.AuthUsecase
+}
+
+func (h *MonitoringHandler) newStreamAuth(ctx *fiber.Ctx) *streamAuth {
+	auth := &streamAuth{authUsecase: h.authUsecase}
+	auth.claims, _ = ctx.Locals("user").(*jwt.Claims)
+	auth.apiKey, _ = ctx.Locals("api_key").(*entity.APIKey)
+	return auth
+}
+
+// check mengembalikan error jika token sudah kedaluwarsa atau dicabut (logout, rotasi, reuse)
+func (a *streamAuth) check(ctx context.Context) error {
+	now := time.Now()
+	if a.apiKey != nil {
+		if !a.apiKey.Active(now) {
+			return errStreamTokenExpired
+		}
+		return nil
+	}
+	if a.claims == nil {
+		return errStreamTokenRevoked
+	}
+	if a.claims.ExpiresAt != nil && !now.Before(a.claims.ExpiresAt.Time) {
+		return errStreamTokenExpired
+	}
+	revoked, err := a.authUsecase.IsTokenRevoked(ctx, a.claims.ID)
+	if err != nil {
+		return fmt.Errorf("unable to verify token: %w", err)
+	}
+	if revoked {
+		return errStreamTokenRevoked
+	}
+	return nil
+}
+
+// parseDeviceFilter membaca query device_ids=a,b,c, kosong berarti semua perangkat milik user
+func parseDeviceFilter(ctx *fiber.Ctx) ([]uuid.UUID, error) {
+	raw := ctx.Query("device_ids")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	for _, part := range strings.Split(raw, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid device_id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// StreamUpgrade memvalidasi filter dan memastikan request adalah upgrade WebSocket sebelum StreamWebSocket
+func (h *MonitoringHandler) StreamUpgrade(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceIDs, err := parseDeviceFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx.Locals("requester", requester)
+	ctx.Locals("device_ids", deviceIDs)
+	ctx.Locals("stream_auth", h.newStreamAuth(ctx))
+	return ctx.Next()
+}
+
+// GET /telemetry/stream/ws?device_ids=a,b
+// Kirim setiap telemetry perangkat milik user sebagai pesan JSON WebSocket
+func (h *MonitoringHandler) StreamWebSocket() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		requester, _ := conn.Locals("requester").(*entity.Requester)
+		deviceIDs, _ := conn.Locals("device_ids").([]uuid.UUID)
+		auth, _ := conn.Locals("stream_auth").(*streamAuth)
+
+		streamCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		messages, closeStream, err := h.usecase.SubscribeTelemetry(streamCtx, requester, deviceIDs)
+		if err != nil {
+			conn.WriteJSON(fiber.Map{"error": err.Error()})
+			return
+		}
+		defer closeStream()
+
+		// Baca pesan dari client hanya untuk mendeteksi koneksi ditutup
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+		authTicker := time.NewTicker(streamAuthInterval)
+		defer authTicker.Stop()
+
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+					return
+				}
+			case <-authTicker.C:
+				if err := auth.check(streamCtx); err != nil {
+					conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(5*time.Second))
+					return
+				}
+			case data, ok := <-messages:
+				if !ok {
+					return
+				}
+				if err := conn.WriteJSON(data); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// GET /telemetry/stream/sse?device_ids=a,b
+// Kirim setiap telemetry perangkat milik user sebagai Server-Sent Events
+func (h *MonitoringHandler) StreamSSE(ctx *fiber.Ctx) error {
+	requester, ok := requesterFromCtx(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+	}
+
+	deviceIDs, err := parseDeviceFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	auth := h.newStreamAuth(ctx)
+	streamCtx, cancel := context.WithCancel(context.Background())
+	messages, closeStream, err := h.usecase.SubscribeTelemetry(streamCtx, requester, deviceIDs)
+	if err != nil {
+		cancel()
+		return ctx.Status(errorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer closeStream()
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+		authTicker := time.NewTicker(streamAuthInterval)
+		defer authTicker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case <-authTicker.C:
+				if err := auth.check(streamCtx); err != nil {
+					payload, _ := json.Marshal(fiber.Map{"error": err.Error()})
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+					w.Flush()
+					return
+				}
+			case data, ok := <-messages:
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(data)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: telemetry\ndata: %s\n\n", payload)
+			}
+
+			// Flush gagal berarti client sudah menutup koneksi
+			if err := w.Flush(); err != nil {
+				log.Printf("SSE client disconnected: %v", err)
+				return
+			}
+		}
+	})
+
+	return nil
+}
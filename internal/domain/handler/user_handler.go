@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	userUsecase iface.UserUsecase
+	validate    *validator.Validate
+}
+
+func NewUserHandler(uu iface.UserUsecase, validate *validator.Validate) *UserHandler {
+	return &UserHandler{
+		userUsecase: uu,
+		validate:    validate,
+	}
+}
+
+// GET /users?limit=20&offset=0
+func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+
+	users, err := h.userUsecase.List(c.Context(), limit, offset)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": users,
+	})
+}
+
+// PUT /users/:id/role
+func (h *UserHandler) UpdateRole(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	req := new(entity.UpdateRoleRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		validationErrors := utils.FormatValidationErrors(err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validationErrors,
+		})
+	}
+
+	user, err := h.userUsecase.UpdateRole(c.Context(), id, req.Role)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"data": user,
+	})
+}
@@ -0,0 +1,37 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AlertRepository interface {
+	CreateRule(ctx context.Context, rule *entity.AlertRule) error
+	GetRuleByID(ctx context.Context, id uuid.UUID) (*entity.AlertRule, error)
+	ListRules(ctx context.Context, userID *uuid.UUID) ([]*entity.AlertRule, error)
+	ListRulesForDevice(ctx context.Context, deviceID uuid.UUID) ([]*entity.AlertRule, error)
+	UpdateRule(ctx context.Context, rule *entity.AlertRule) error
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+
+	GetActiveAlert(ctx context.Context, ruleID, deviceID uuid.UUID) (*entity.Alert, error)
+	GetLastResolvedAt(ctx context.Context, ruleID, deviceID uuid.UUID) (*time.Time, error)
+	CreateAlert(ctx context.Context, alert *entity.Alert) error
+	UpdateAlert(ctx context.Context, alert *entity.Alert) error
+	DeleteAlert(ctx context.Context, id uuid.UUID) error
+	ListAlerts(ctx context.Context, query *entity.AlertQuery) ([]*entity.Alert, error)
+}
+
+type AlertUseCase interface {
+	CreateRule(ctx context.Context, requester *entity.Requester, req *entity.AlertRuleRequest) (*entity.AlertRule, error)
+	GetRule(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.AlertRule, error)
+	ListRules(ctx context.Context, requester *entity.Requester) ([]*entity.AlertRule, error)
+	UpdateRule(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.AlertRuleRequest) (*entity.AlertRule, error)
+	DeleteRule(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+	ListAlerts(ctx context.Context, requester *entity.Requester, query *entity.AlertQuery) ([]*entity.Alert, error)
+
+	// Evaluate menjalankan semua rule yang berlaku untuk perangkat terhadap satu data telemetry
+	Evaluate(ctx context.Context, data *entity.MonitoringData) error
+}
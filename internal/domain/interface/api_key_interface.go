@@ -0,0 +1,27 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time, olderThan time.Time) error
+}
+
+type APIKeyUseCase interface {
+	Create(ctx context.Context, requester *entity.Requester, req *entity.APIKeyRequest) (*entity.APIKeyCreated, error)
+	List(ctx context.Context, requester *entity.Requester) ([]*entity.APIKey, error)
+	Revoke(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+
+	// Authenticate mencari key aktif dari plaintext X-API-Key beserta pemiliknya dan mencatat last_used_at
+	Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error)
+}
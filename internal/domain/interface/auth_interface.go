@@ -19,6 +19,17 @@ type UserRepository interface {
 
 type AuthUsecase interface {
 	Register(ctx context.Context, req *entity.RegisterRequest) error
-	Login(ctx context.Context, username, password string) (accessToken, refreshToken string, err error)
-	RefreshToken(ctx context.Context, refreshToken string) (newAccessToken, newRefreshToken string, err error)
+	Login(ctx context.Context, username, password string, meta *entity.SessionMeta) (accessToken, refreshToken string, err error)
+	RefreshToken(ctx context.Context, refreshToken string, meta *entity.SessionMeta) (newAccessToken, newRefreshToken string, err error)
+	Logout(ctx context.Context, userID uuid.UUID, sessionID string) error
+	LogoutAll(ctx context.Context, userID uuid.UUID) error
+	ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]*entity.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type UserUsecase interface {
+	List(ctx context.Context, limit, offset int) ([]*entity.User, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, role string) (*entity.User, error)
+	EnsureAdmin(ctx context.Context, username, email, password string) error
 }
@@ -0,0 +1,33 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CommandRepository interface {
+	Create(ctx context.Context, command *entity.DeviceCommand) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.DeviceCommand, error)
+	List(ctx context.Context, query *entity.CommandQuery) ([]*entity.DeviceCommand, error)
+	// Transition mengubah status hanya jika status saat ini salah satu dari from, changed true jika baris berubah
+	Transition(ctx context.Context, command *entity.DeviceCommand, from []string) (changed bool, err error)
+	ListExpired(ctx context.Context, now time.Time) ([]*entity.DeviceCommand, error)
+}
+
+// CommandPublisher mengirim perintah ke perangkat, diimplementasikan oleh client MQTT
+type CommandPublisher interface {
+	PublishCommand(deviceID uuid.UUID, msg *entity.CommandMessage) error
+}
+
+type CommandUseCase interface {
+	Send(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, req *entity.CommandRequest) (*entity.DeviceCommand, error)
+	Get(ctx context.Context, requester *entity.Requester, deviceID, id uuid.UUID) (*entity.DeviceCommand, error)
+	List(ctx context.Context, requester *entity.Requester, query *entity.CommandQuery) ([]*entity.DeviceCommand, error)
+	// HandleResponse memproses balasan perangkat, balasan untuk perintah perangkat lain atau yang sudah selesai diabaikan
+	HandleResponse(ctx context.Context, deviceID uuid.UUID, resp *entity.CommandResponse) error
+	// StartTimeoutSweeper menandai timed_out perintah yang melewati expires_at, blocking sampai ctx selesai
+	StartTimeoutSweeper(ctx context.Context, interval time.Duration)
+}
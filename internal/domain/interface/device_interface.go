@@ -3,6 +3,7 @@ package iface
 import (
 	"context"
 	"monitoring/internal/domain/entity"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,10 +17,19 @@ type DeviceRepository interface {
 	UpdateOnlineStatus(ctx context.Context, deviceID uuid.UUID, isOnline bool) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, limit, offset int) ([]*entity.Device, error)
+	ListFiltered(ctx context.Context, query *entity.DeviceListQuery) (*entity.DeviceList, error)
+	// Touch memperbarui last_seen dan menandai perangkat online, cameOnline true jika sebelumnya offline
+	Touch(ctx context.Context, deviceID uuid.UUID) (cameOnline bool, err error)
+	// SetOnline mengubah status online hanya jika berbeda, changed true jika terjadi transisi
+	SetOnline(ctx context.Context, deviceID uuid.UUID, isOnline bool) (changed bool, err error)
+	ListStaleOnline(ctx context.Context, lastSeenBefore time.Time) ([]*entity.Device, error)
+	GetByTokenHash(ctx context.Context, hash string) (*entity.Device, error)
+	SetTokenHash(ctx context.Context, deviceID uuid.UUID, hash string) error
 }
 
 type DeviceUseCase interface {
-	Create(ctx context.Context, device *entity.Device) error
+	// Create menyimpan perangkat baru sekaligus membuat token perangkat, token plaintext hanya dikembalikan sekali
+	Create(ctx context.Context, device *entity.Device) (token string, err error)
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.Device, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.Device, error)
 	GetByMacAddress(ctx context.Context, macAddress string) (*entity.Device, error)
@@ -27,4 +37,19 @@ type DeviceUseCase interface {
 	UpdateOnlineStatus(ctx context.Context, deviceID uuid.UUID, isOnline bool) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, limit, offset int) ([]*entity.Device, error)
+	ListOwned(ctx context.Context, requester *entity.Requester, query *entity.DeviceListQuery) (*entity.DeviceList, error)
+	GetOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.Device, error)
+	UpdateOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.DeviceUpdateRequest) (*entity.Device, error)
+	DeleteOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+	// RotateToken membuat token perangkat baru, token lama langsung tidak berlaku
+	RotateToken(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.DeviceCredential, error)
+	// AuthenticateToken mencari perangkat dari token yang dikirim perangkat
+	AuthenticateToken(ctx context.Context, token string) (*entity.Device, error)
+
+	// Heartbeat dipanggil setiap ada telemetry masuk dari perangkat
+	Heartbeat(ctx context.Context, deviceID uuid.UUID) error
+	// ReportStatus dipanggil saat perangkat (atau Last Will-nya) mempublikasikan status online/offline
+	ReportStatus(ctx context.Context, deviceID uuid.UUID, isOnline bool) error
+	// StartOfflineSweeper menandai offline perangkat yang tidak mengirim data selama offlineAfter, blocking sampai ctx selesai
+	StartOfflineSweeper(ctx context.Context, interval, offlineAfter time.Duration)
 }
@@ -0,0 +1,63 @@
+package iface
+
+import (
+	"context"
+	"io"
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// ArtifactStore menyimpan file firmware, diimplementasikan oleh storage.LocalStore
+type ArtifactStore interface {
+	Save(ctx context.Context, key string, r io.Reader) (size int64, checksum string, err error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// OTAPublisher memberi tahu perangkat tentang firmware baru, diimplementasikan oleh client MQTT.
+// notification nil menghapus notifikasi retained perangkat tersebut.
+type OTAPublisher interface {
+	PublishOTA(deviceID uuid.UUID, notification *entity.OTANotification) error
+}
+
+type FirmwareRepository interface {
+	Create(ctx context.Context, firmware *entity.Firmware) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.Firmware, error)
+	List(ctx context.Context, userID *uuid.UUID) ([]*entity.Firmware, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	CountRollouts(ctx context.Context, firmwareID uuid.UUID) (int64, error)
+
+	CreateRollout(ctx context.Context, rollout *entity.FirmwareRollout, devices []*entity.RolloutDevice) error
+	GetRollout(ctx context.Context, id uuid.UUID) (*entity.FirmwareRollout, error)
+	ListRollouts(ctx context.Context, userID *uuid.UUID) ([]*entity.FirmwareRollout, error)
+	// SetRolloutStatus mengubah status hanya jika status saat ini salah satu dari from, changed true jika baris berubah
+	SetRolloutStatus(ctx context.Context, rollout *entity.FirmwareRollout, from []string) (changed bool, err error)
+	// AdvanceStage menaikkan current_stage rollout yang masih running dari stage, changed false jika sudah dinaikkan proses lain
+	AdvanceStage(ctx context.Context, id uuid.UUID, stage int) (changed bool, err error)
+	RolloutStats(ctx context.Context, id uuid.UUID) (*entity.RolloutStats, error)
+
+	ListRolloutDevices(ctx context.Context, rolloutID uuid.UUID, stage *int) ([]*entity.RolloutDevice, error)
+	GetRolloutDevice(ctx context.Context, rolloutID, deviceID uuid.UUID) (*entity.RolloutDevice, error)
+	UpdateRolloutDevice(ctx context.Context, device *entity.RolloutDevice, from []string) (changed bool, err error)
+	// ActiveRolloutDevices mengembalikan perangkat dari deviceIDs yang masih ikut rollout running/halted
+	ActiveRolloutDevices(ctx context.Context, deviceIDs []uuid.UUID) ([]uuid.UUID, error)
+}
+
+type FirmwareUseCase interface {
+	Upload(ctx context.Context, requester *entity.Requester, req *entity.FirmwareRequest, artifact io.Reader) (*entity.Firmware, error)
+	Get(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.Firmware, error)
+	List(ctx context.Context, requester *entity.Requester) ([]*entity.Firmware, error)
+	Delete(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+	// OpenArtifact membuka file firmware untuk perangkat yang bertipe sama dan dimiliki pemilik firmware
+	OpenArtifact(ctx context.Context, device *entity.Device, id uuid.UUID) (*entity.Firmware, io.ReadCloser, error)
+
+	CreateRollout(ctx context.Context, requester *entity.Requester, req *entity.RolloutRequest) (*entity.FirmwareRollout, error)
+	GetRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error)
+	ListRollouts(ctx context.Context, requester *entity.Requester) ([]*entity.FirmwareRollout, error)
+	HaltRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error)
+	ResumeRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error)
+	CancelRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error)
+	// ReportProgress memproses progres dari perangkat lalu menghentikan atau melanjutkan rollout
+	ReportProgress(ctx context.Context, deviceID uuid.UUID, progress *entity.OTAProgress) error
+}
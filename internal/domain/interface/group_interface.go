@@ -0,0 +1,32 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GroupRepository interface {
+	Create(ctx context.Context, group *entity.DeviceGroup) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.DeviceGroup, error)
+	List(ctx context.Context, userID *uuid.UUID) ([]*entity.DeviceGroup, error)
+	Update(ctx context.Context, group *entity.DeviceGroup) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddDevices(ctx context.Context, groupID uuid.UUID, devices []*entity.Device) error
+	RemoveDevice(ctx context.Context, groupID, deviceID uuid.UUID) error
+}
+
+type GroupUseCase interface {
+	Create(ctx context.Context, requester *entity.Requester, req *entity.DeviceGroupRequest) (*entity.DeviceGroup, error)
+	Get(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.DeviceGroup, error)
+	List(ctx context.Context, requester *entity.Requester) ([]*entity.DeviceGroup, error)
+	Update(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.DeviceGroupRequest) (*entity.DeviceGroup, error)
+	Delete(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+	AddDevices(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.GroupMembersRequest) (*entity.DeviceGroup, error)
+	RemoveDevice(ctx context.Context, requester *entity.Requester, id, deviceID uuid.UUID) error
+
+	// GetSeries mengambil time-series group dari InfluxDB, mode fleet (agregat) atau device (per perangkat)
+	GetSeries(ctx context.Context, requester *entity.Requester, id uuid.UUID, startTime, endTime time.Time, every time.Duration, fn, mode string, metrics []string) (*entity.GroupSeries, error)
+}
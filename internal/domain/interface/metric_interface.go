@@ -0,0 +1,26 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+type MetricRepository interface {
+	Create(ctx context.Context, def *entity.MetricDefinition) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.MetricDefinition, error)
+	// List mengembalikan definisi untuk deviceType, kosong berarti semua tipe perangkat
+	List(ctx context.Context, deviceType string) ([]*entity.MetricDefinition, error)
+	ListByName(ctx context.Context, name string) ([]*entity.MetricDefinition, error)
+	Update(ctx context.Context, def *entity.MetricDefinition) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type MetricUseCase interface {
+	// List menggabungkan metric bawaan dengan katalog deviceType, kosong berarti semua tipe perangkat
+	List(ctx context.Context, deviceType string) ([]*entity.MetricDefinition, error)
+	Create(ctx context.Context, req *entity.MetricDefinitionRequest) (*entity.MetricDefinition, error)
+	Update(ctx context.Context, id uuid.UUID, req *entity.MetricDefinitionUpdateRequest) (*entity.MetricDefinition, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -9,17 +9,35 @@ import (
 )
 
 type MonitoringRepository interface {
+	// Store menulis asinkron lewat batch, StoreBatch menunggu sampai titik tersimpan
 	Store(ctx context.Context, data *entity.MonitoringData) error
+	StoreBatch(ctx context.Context, data []*entity.MonitoringData) error
+	// ReplaySpool mengirim ulang titik yang disimpan ke disk selama InfluxDB tidak tersedia
+	ReplaySpool(ctx context.Context) (int, error)
+	SpoolStats() *entity.SpoolStats
 	GetByDeviceID(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error)
 	GetLatestByDeviceID(ctx context.Context, deviceID uuid.UUID) (*entity.MonitoringData, error)
-	GetStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time) (*entity.MonitoringStats, error)
+	// metrics adalah nama field numerik yang diambil, harus sudah divalidasi pemanggil karena disisipkan ke query Flux
+	GetSeries(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string) ([]*entity.MonitoringData, error)
+	// GetGroupSeries memfilter titik dengan tag group_<groupID>; perDevice false berarti diagregasi lintas perangkat (DeviceID kosong)
+	GetGroupSeries(ctx context.Context, groupID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string, perDevice bool) ([]*entity.MonitoringData, error)
+	GetStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, metrics []string) (*entity.MonitoringStats, error)
 	DeleteOldData(ctx context.Context, retentionPeriod time.Duration) error
 }
 
 type MonitoringUseCase interface {
 	StoreMonitoringData(ctx context.Context, data *entity.MonitoringData) error
-	GetMonitoringDataByDevice(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error)
-	GetLatestMonitoringData(ctx context.Context, deviceID uuid.UUID) (*entity.MonitoringData, error)
-	GetMonitoringStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time) (*entity.MonitoringStats, error)
+	// StoreMonitoringBatch menyimpan semua data secara blocking (semua atau tidak sama sekali)
+	StoreMonitoringBatch(ctx context.Context, data []*entity.MonitoringData) error
+	GetMonitoringDataByDevice(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error)
+	GetLatestMonitoringData(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) (*entity.MonitoringData, error)
+	GetLatestForOwnedDevices(ctx context.Context, requester *entity.Requester) ([]*entity.DeviceResponse, error)
+	// metrics kosong berarti empat metric bawaan
+	GetMonitoringSeries(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string) (*entity.MonitoringSeries, error)
+	GetMonitoringStats(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, metrics []string) (*entity.MonitoringStats, error)
 	DeleteOldMonitoringData(ctx context.Context, retentionPeriod time.Duration) error
+	SubscribeTelemetry(ctx context.Context, requester *entity.Requester, deviceIDs []uuid.UUID) (<-chan *entity.MonitoringData, func(), error)
+	// StartSpoolReplayer mengosongkan spool telemetry setiap interval, blocking sampai ctx selesai
+	StartSpoolReplayer(ctx context.Context, interval time.Duration)
+	GetSpoolStats(ctx context.Context) *entity.SpoolStats
 }
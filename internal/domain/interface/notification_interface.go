@@ -0,0 +1,40 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+type NotificationRepository interface {
+	CreateChannel(ctx context.Context, channel *entity.NotificationChannel) error
+	GetChannelByID(ctx context.Context, id uuid.UUID) (*entity.NotificationChannel, error)
+	ListChannels(ctx context.Context, userID *uuid.UUID) ([]*entity.NotificationChannel, error)
+	ListEnabledChannels(ctx context.Context, userID uuid.UUID) ([]*entity.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, channel *entity.NotificationChannel) error
+	DeleteChannel(ctx context.Context, id uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, delivery *entity.NotificationDelivery) error
+	ListDeliveries(ctx context.Context, channelID uuid.UUID, limit, offset int) ([]*entity.NotificationDelivery, error)
+}
+
+// EventPublisher dipakai usecase lain untuk menerbitkan event domain tanpa bergantung pada cara pengirimannya
+type EventPublisher interface {
+	Publish(ctx context.Context, event *entity.Event)
+}
+
+type NotificationUseCase interface {
+	EventPublisher
+
+	CreateChannel(ctx context.Context, requester *entity.Requester, req *entity.NotificationChannelRequest) (*entity.NotificationChannel, error)
+	GetChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.NotificationChannel, error)
+	ListChannels(ctx context.Context, requester *entity.Requester) ([]*entity.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.NotificationChannelRequest) (*entity.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, requester *entity.Requester, channelID uuid.UUID, limit, offset int) ([]*entity.NotificationDelivery, error)
+	TestChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) error
+
+	// Start menjalankan worker pengiriman webhook, blocking sampai ctx selesai
+	Start(ctx context.Context)
+}
@@ -0,0 +1,17 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+)
+
+type ProvisioningUseCase interface {
+	// Request mendaftarkan perangkat yang belum diklaim dan menghasilkan claim code
+	Request(ctx context.Context, req *entity.ProvisionRequest) (*entity.ProvisionTicket, error)
+	// Poll dipanggil perangkat untuk mengambil kredensial setelah diklaim, kredensial hanya diberikan sekali
+	Poll(ctx context.Context, provisionID string) (*entity.ProvisionStatus, error)
+	// WaitForClaim memblok sampai provisionID diklaim (mengembalikan hasil Poll berisi kredensial) atau kedaluwarsa
+	WaitForClaim(ctx context.Context, provisionID string) (*entity.ProvisionStatus, error)
+	// Claim membuat entity.Device untuk requester dari claim code
+	Claim(ctx context.Context, requester *entity.Requester, req *entity.ClaimRequest) (*entity.Device, error)
+}
@@ -0,0 +1,29 @@
+package iface
+
+import (
+	"context"
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+type ShadowRepository interface {
+	// Get mengembalikan shadow kosong (version 0) jika perangkat belum memiliki shadow
+	Get(ctx context.Context, deviceID uuid.UUID) (*entity.DeviceShadow, error)
+	// Update menjalankan fn terhadap shadow yang dikunci (SELECT ... FOR UPDATE) lalu menyimpannya
+	Update(ctx context.Context, deviceID uuid.UUID, fn func(shadow *entity.DeviceShadow) error) (*entity.DeviceShadow, error)
+}
+
+// ShadowPublisher mengirim delta shadow ke perangkat, diimplementasikan oleh client MQTT
+type ShadowPublisher interface {
+	PublishShadowDelta(deviceID uuid.UUID, delta *entity.ShadowDelta) error
+}
+
+type ShadowUseCase interface {
+	Get(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) (*entity.DeviceShadow, error)
+	UpdateDesired(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, req *entity.ShadowUpdateRequest) (*entity.DeviceShadow, error)
+	// ReportState menggabungkan state yang dilaporkan perangkat dan mempublikasikan ulang delta
+	ReportState(ctx context.Context, deviceID uuid.UUID, state map[string]interface{}) (*entity.DeviceShadow, error)
+	// SyncDelta mempublikasikan delta terkini, dipanggil saat perangkat meminta shadow-nya
+	SyncDelta(ctx context.Context, deviceID uuid.UUID) error
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type alertRepository struct {
+	db *gorm.DB
+}
+
+func NewAlertRepository(db *gorm.DB) iface.AlertRepository {
+	return &alertRepository{db: db}
+}
+
+func (r *alertRepository) CreateRule(ctx context.Context, rule *entity.AlertRule) error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+func (r *alertRepository) GetRuleByID(ctx context.Context, id uuid.UUID) (*entity.AlertRule, error) {
+	var rule entity.AlertRule
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get alert rule by id: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *alertRepository) ListRules(ctx context.Context, userID *uuid.UUID) ([]*entity.AlertRule, error) {
+	var rules []*entity.AlertRule
+	query := r.db.WithContext(ctx).Order("created_at desc")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ListRulesForDevice mengembalikan rule aktif yang menargetkan perangkat secara langsung, lewat group
+// yang berisi perangkat, atau rule tanpa device_id/group_id milik pemilik perangkat tersebut.
+func (r *alertRepository) ListRulesForDevice(ctx context.Context, deviceID uuid.UUID) ([]*entity.AlertRule, error) {
+	var rules []*entity.AlertRule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Where("device_id = ? OR group_id IN (SELECT device_group_id FROM device_group_members WHERE device_id = ?) OR "+
+			"(device_id IS NULL AND group_id IS NULL AND user_id = (SELECT user_id FROM devices WHERE id = ?))", deviceID, deviceID, deviceID).
+		Find(&rules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules for device: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *alertRepository) UpdateRule(ctx context.Context, rule *entity.AlertRule) error {
+	if err := r.db.WithContext(ctx).Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
+
+func (r *alertRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", id).Delete(&entity.Alert{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.AlertRule{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
+
+// GetLastResolvedAt mengembalikan waktu resolve terakhir untuk pasangan rule dan perangkat, nil jika belum pernah
+func (r *alertRepository) GetLastResolvedAt(ctx context.Context, ruleID, deviceID uuid.UUID) (*time.Time, error) {
+	var alert entity.Alert
+	err := r.db.WithContext(ctx).
+		Where("rule_id = ? AND device_id = ? AND state = ?", ruleID, deviceID, entity.AlertStateResolved).
+		Order("resolved_at DESC").
+		First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last resolved alert: %w", err)
+	}
+	return alert.ResolvedAt, nil
+}
+
+// GetActiveAlert mengembalikan alert pending/firing untuk pasangan rule dan perangkat, nil jika tidak ada
+func (r *alertRepository) GetActiveAlert(ctx context.Context, ruleID, deviceID uuid.UUID) (*entity.Alert, error) {
+	var alert entity.Alert
+	err := r.db.WithContext(ctx).
+		Where("rule_id = ? AND device_id = ? AND state IN ?", ruleID, deviceID, []string{entity.AlertStatePending, entity.AlertStateFiring}).
+		First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get active alert: %w", err)
+	}
+	return &alert, nil
+}
+
+func (r *alertRepository) CreateAlert(ctx context.Context, alert *entity.Alert) error {
+	if err := r.db.WithContext(ctx).Create(alert).Error; err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	return nil
+}
+
+func (r *alertRepository) UpdateAlert(ctx context.Context, alert *entity.Alert) error {
+	if err := r.db.WithContext(ctx).Omit("Rule").Save(alert).Error; err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	return nil
+}
+
+func (r *alertRepository) DeleteAlert(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&entity.Alert{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	return nil
+}
+
+func (r *alertRepository) ListAlerts(ctx context.Context, q *entity.AlertQuery) ([]*entity.Alert, error) {
+	query := r.db.WithContext(ctx).Preload("Rule").Order("started_at desc")
+	if q.UserID != nil {
+		query = query.Where("user_id = ?", *q.UserID)
+	}
+	if q.DeviceID != nil {
+		query = query.Where("device_id = ?", *q.DeviceID)
+	}
+	switch q.State {
+	case "", "active":
+		query = query.Where("state IN ?", []string{entity.AlertStatePending, entity.AlertStateFiring})
+	default:
+		query = query.Where("state = ?", q.State)
+	}
+
+	var alerts []*entity.Alert
+	if err := query.Limit(q.Limit).Offset(q.Offset).Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return alerts, nil
+}
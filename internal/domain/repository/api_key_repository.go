@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) iface.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	if err := r.db.WithContext(ctx).Omit("Owner").Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key by id: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).Preload("Owner").Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key by hash: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// TouchLastUsed hanya menulis jika last_used_at lebih lama dari olderThan agar tidak ada write di setiap request
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time, olderThan time.Time) error {
+	err := r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, olderThan).
+		Update("last_used_at", usedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type commandRepository struct {
+	db *gorm.DB
+}
+
+func NewCommandRepository(db *gorm.DB) iface.CommandRepository {
+	return &commandRepository{db: db}
+}
+
+func (r *commandRepository) Create(ctx context.Context, command *entity.DeviceCommand) error {
+	if err := r.db.WithContext(ctx).Create(command).Error; err != nil {
+		return fmt.Errorf("failed to create device command: %w", err)
+	}
+	return nil
+}
+
+func (r *commandRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.DeviceCommand, error) {
+	var command entity.DeviceCommand
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&command).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrCommandNotFound
+		}
+		return nil, fmt.Errorf("failed to get device command by id: %w", err)
+	}
+	return &command, nil
+}
+
+func (r *commandRepository) List(ctx context.Context, q *entity.CommandQuery) ([]*entity.DeviceCommand, error) {
+	query := r.db.WithContext(ctx).Where("device_id = ?", q.DeviceID).Order("created_at desc")
+	switch q.Status {
+	case "":
+	case "pending":
+		query = query.Where("status IN ?", entity.CommandPendingStatuses)
+	default:
+		query = query.Where("status = ?", q.Status)
+	}
+
+	var commands []*entity.DeviceCommand
+	if err := query.Limit(q.Limit).Offset(q.Offset).Find(&commands).Error; err != nil {
+		return nil, fmt.Errorf("failed to list device commands: %w", err)
+	}
+	return commands, nil
+}
+
+// Transition memakai kondisi status di WHERE agar balasan yang datang bersamaan atau
+// terlambat (setelah timeout) tidak menimpa status akhir
+func (r *commandRepository) Transition(ctx context.Context, command *entity.DeviceCommand, from []string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.DeviceCommand{}).
+		Where("id = ? AND status IN ?", command.ID, from).
+		Select("status", "result", "error", "sent_at", "acked_at", "completed_at", "updated_at").
+		Updates(command)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update device command status: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *commandRepository) ListExpired(ctx context.Context, now time.Time) ([]*entity.DeviceCommand, error) {
+	var commands []*entity.DeviceCommand
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND expires_at < ?", entity.CommandPendingStatuses, now).
+		Find(&commands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired device commands: %w", err)
+	}
+	return commands, nil
+}
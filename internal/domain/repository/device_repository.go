@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,12 +32,33 @@ func (r *deviceRepository) Create(ctx context.Context, device *entity.Device) er
 
 func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.Device, error) {
 	var device entity.Device
-	if err := r.db.WithContext(ctx).Preload("User").Where("id = ?", id).First(&device).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("User").Preload("Groups").Where("id = ?", id).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrDeviceNotFound
+		}
 		return nil, fmt.Errorf("failed to get device by id: %w", err)
 	}
 	return &device, nil
 }
 
+func (r *deviceRepository) GetByTokenHash(ctx context.Context, hash string) (*entity.Device, error) {
+	var device entity.Device
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrDeviceNotFound
+		}
+		return nil, fmt.Errorf("failed to get device by token: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *deviceRepository) SetTokenHash(ctx context.Context, deviceID uuid.UUID, hash string) error {
+	if err := r.db.WithContext(ctx).Model(&entity.Device{}).Where("id = ?", deviceID).Update("token_hash", hash).Error; err != nil {
+		return fmt.Errorf("failed to update device token: %w", err)
+	}
+	return nil
+}
+
 func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.Device, error) {
 	var devices []*entity.Device
 	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&devices).Error; err != nil {
@@ -45,13 +70,16 @@ func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([
 func (r *deviceRepository) GetByMacAddress(ctx context.Context, macAddress string) (*entity.Device, error) {
 	var device entity.Device
 	if err := r.db.WithContext(ctx).Where("mac_address = ?", macAddress).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrDeviceNotFound
+		}
 		return nil, fmt.Errorf("failed to get device by mac address: %w", err)
 	}
 	return &device, nil
 }
 
 func (r *deviceRepository) Update(ctx context.Context, device *entity.Device) error {
-	if err := r.db.WithContext(ctx).Save(device).Error; err != nil {
+	if err := r.db.WithContext(ctx).Omit("User", "Groups").Save(device).Error; err != nil {
 		return fmt.Errorf("failed to update device: %w", err)
 	}
 	return nil
@@ -69,8 +97,85 @@ func (r *deviceRepository) UpdateOnlineStatus(ctx context.Context, deviceID uuid
 	return nil
 }
 
+func (r *deviceRepository) Touch(ctx context.Context, deviceID uuid.UUID) (bool, error) {
+	// Jalur umum: perangkat sudah online, cukup perbarui last_seen
+	res := r.db.WithContext(ctx).Model(&entity.Device{}).
+		Where("id = ? AND is_online = ?", deviceID, true).
+		Update("last_seen", time.Now())
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to touch device: %w", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return false, nil
+	}
+
+	return r.SetOnline(ctx, deviceID, true)
+}
+
+func (r *deviceRepository) SetOnline(ctx context.Context, deviceID uuid.UUID, isOnline bool) (bool, error) {
+	updates := map[string]interface{}{
+		"is_online": isOnline,
+	}
+	if isOnline {
+		updates["last_seen"] = time.Now()
+	}
+
+	res := r.db.WithContext(ctx).Model(&entity.Device{}).
+		Where("id = ? AND is_online = ?", deviceID, !isOnline).
+		Updates(updates)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to set device online status: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *deviceRepository) ListStaleOnline(ctx context.Context, lastSeenBefore time.Time) ([]*entity.Device, error) {
+	var devices []*entity.Device
+	if err := r.db.WithContext(ctx).Where("is_online = ? AND last_seen < ?", true, lastSeenBefore).Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stale devices: %w", err)
+	}
+	return devices, nil
+}
+
+// Delete menghapus perangkat beserta semua data yang mereferensikannya dalam satu transaksi
 func (r *deviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := r.db.WithContext(ctx).Delete(&entity.Device{}, id).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Device{ID: id}).Association("Groups").Clear(); err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&entity.DeviceShadow{},
+			&entity.Alert{},
+			&entity.AlertRule{},
+			&entity.DeviceCommand{},
+			&entity.RolloutDevice{},
+		} {
+			if err := tx.Where("device_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// device_ids disimpan sebagai array JSON string uuid
+		target := fmt.Sprintf(`["%s"]`, id)
+		// API key yang hanya dibatasi ke perangkat ini dicabut, karena device_ids kosong berarti semua perangkat
+		if err := tx.Model(&entity.APIKey{}).
+			Where("revoked_at IS NULL AND device_ids = ?::jsonb", target).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.APIKey{}).
+			Where("device_ids @> ?::jsonb AND jsonb_array_length(device_ids) > 1", target).
+			Update("device_ids", gorm.Expr("device_ids - ?::text", id.String())).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.FirmwareRollout{}).
+			Where("device_ids @> ?::jsonb", target).
+			Update("device_ids", gorm.Expr("device_ids - ?::text", id.String())).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Device{}, id).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete device: %w", err)
 	}
 	return nil
@@ -83,3 +188,136 @@ func (r *deviceRepository) List(ctx context.Context, limit, offset int) ([]*enti
 	}
 	return devices, nil
 }
+
+type deviceCursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// deviceTimeColumns adalah kolom sort bertipe timestamp, nilai cursor-nya disimpan dalam RFC3339Nano
+var deviceTimeColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"last_seen":  true,
+}
+
+func (r *deviceRepository) ListFiltered(ctx context.Context, q *entity.DeviceListQuery) (*entity.DeviceList, error) {
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	order := q.Order
+	if order == "" {
+		order = "desc"
+	}
+
+	query := r.db.WithContext(ctx).Model(&entity.Device{})
+	if q.UserID != nil {
+		query = query.Where("user_id = ?", *q.UserID)
+	}
+	if len(q.IDs) > 0 {
+		query = query.Where("id IN ?", q.IDs)
+	}
+	if q.Type != "" {
+		query = query.Where("type = ?", q.Type)
+	}
+	if q.Location != "" {
+		query = query.Where("location ILIKE ?", "%"+q.Location+"%")
+	}
+	if q.IsOnline != nil {
+		query = query.Where("is_online = ?", *q.IsOnline)
+	}
+	if q.GroupID != nil {
+		query = query.Where("id IN (?)", r.db.Table("device_group_members").Select("device_id").Where("device_group_id = ?", *q.GroupID))
+	}
+	for _, tag := range q.Tags {
+		key, value, _ := strings.Cut(tag, ":")
+		contains, _ := json.Marshal(map[string]string{key: value})
+		query = query.Where("tags @> ?::jsonb", string(contains))
+	}
+	// session agar query dasar bisa dipakai ulang untuk count dan pengambilan data
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count devices: %w", err)
+	}
+
+	page := query.Preload("User").Preload("Groups").
+		Order(fmt.Sprintf("%s %s, id %s", sortBy, order, order)).
+		Limit(q.Limit)
+
+	if q.Cursor != "" {
+		cursorValue, cursorID, err := decodeDeviceCursor(q.Cursor, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if order == "desc" {
+			op = "<"
+		}
+		page = page.Where(fmt.Sprintf("(%s, id) %s (?, ?)", sortBy, op), cursorValue, cursorID)
+	} else {
+		page = page.Offset(q.Offset)
+	}
+
+	var devices []*entity.Device
+	if err := page.Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	result := &entity.DeviceList{
+		Devices: devices,
+		Total:   total,
+		Limit:   q.Limit,
+		Offset:  q.Offset,
+	}
+	if len(devices) == q.Limit && q.Limit > 0 {
+		result.NextCursor = encodeDeviceCursor(devices[len(devices)-1], sortBy)
+	}
+
+	return result, nil
+}
+
+func encodeDeviceCursor(device *entity.Device, sortBy string) string {
+	var value string
+	switch sortBy {
+	case "name":
+		value = device.Name
+	case "type":
+		value = device.Type
+	case "location":
+		value = device.Location
+	case "updated_at":
+		value = device.UpdatedAt.Format(time.RFC3339Nano)
+	case "last_seen":
+		value = device.LastSeen.Format(time.RFC3339Nano)
+	default:
+		value = device.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(deviceCursor{Value: value, ID: device.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeDeviceCursor(cursor, sortBy string) (interface{}, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, uuid.Nil, entity.ErrInvalidCursor
+	}
+
+	var c deviceCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, uuid.Nil, entity.ErrInvalidCursor
+	}
+
+	if deviceTimeColumns[sortBy] {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, uuid.Nil, entity.ErrInvalidCursor
+		}
+		return t, c.ID, nil
+	}
+
+	return c.Value, c.ID, nil
+}
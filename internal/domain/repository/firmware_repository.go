@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type firmwareRepository struct {
+	db *gorm.DB
+}
+
+func NewFirmwareRepository(db *gorm.DB) iface.FirmwareRepository {
+	return &firmwareRepository{db: db}
+}
+
+func (r *firmwareRepository) Create(ctx context.Context, firmware *entity.Firmware) error {
+	if err := r.db.WithContext(ctx).Create(firmware).Error; err != nil {
+		return fmt.Errorf("failed to create firmware: %w", err)
+	}
+	return nil
+}
+
+func (r *firmwareRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.Firmware, error) {
+	var firmware entity.Firmware
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&firmware).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrFirmwareNotFound
+		}
+		return nil, fmt.Errorf("failed to get firmware by id: %w", err)
+	}
+	return &firmware, nil
+}
+
+func (r *firmwareRepository) List(ctx context.Context, userID *uuid.UUID) ([]*entity.Firmware, error) {
+	var firmwares []*entity.Firmware
+	query := r.db.WithContext(ctx).Order("created_at desc")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&firmwares).Error; err != nil {
+		return nil, fmt.Errorf("failed to list firmware: %w", err)
+	}
+	return firmwares, nil
+}
+
+func (r *firmwareRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&entity.Firmware{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete firmware: %w", err)
+	}
+	return nil
+}
+
+func (r *firmwareRepository) CountRollouts(ctx context.Context, firmwareID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&entity.FirmwareRollout{}).Where("firmware_id = ?", firmwareID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count firmware rollouts: %w", err)
+	}
+	return count, nil
+}
+
+func (r *firmwareRepository) CreateRollout(ctx context.Context, rollout *entity.FirmwareRollout, devices []*entity.RolloutDevice) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Firmware").Create(rollout).Error; err != nil {
+			return err
+		}
+		for _, device := range devices {
+			device.RolloutID = rollout.ID
+		}
+		return tx.CreateInBatches(devices, 500).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create firmware rollout: %w", err)
+	}
+	return nil
+}
+
+func (r *firmwareRepository) GetRollout(ctx context.Context, id uuid.UUID) (*entity.FirmwareRollout, error) {
+	var rollout entity.FirmwareRollout
+	if err := r.db.WithContext(ctx).Preload("Firmware").Where("id = ?", id).First(&rollout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrRolloutNotFound
+		}
+		return nil, fmt.Errorf("failed to get firmware rollout by id: %w", err)
+	}
+	return &rollout, nil
+}
+
+func (r *firmwareRepository) ListRollouts(ctx context.Context, userID *uuid.UUID) ([]*entity.FirmwareRollout, error) {
+	var rollouts []*entity.FirmwareRollout
+	query := r.db.WithContext(ctx).Preload("Firmware").Order("created_at desc")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&rollouts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list firmware rollouts: %w", err)
+	}
+	return rollouts, nil
+}
+
+func (r *firmwareRepository) SetRolloutStatus(ctx context.Context, rollout *entity.FirmwareRollout, from []string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.FirmwareRollout{}).
+		Where("id = ? AND status IN ?", rollout.ID, from).
+		Select("status", "halt_reason", "completed_at", "updated_at").
+		Updates(rollout)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update firmware rollout status: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *firmwareRepository) AdvanceStage(ctx context.Context, id uuid.UUID, stage int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.FirmwareRollout{}).
+		Where("id = ? AND status = ? AND current_stage = ?", id, entity.RolloutStatusRunning, stage).
+		Update("current_stage", stage+1)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to advance firmware rollout stage: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *firmwareRepository) RolloutStats(ctx context.Context, id uuid.UUID) (*entity.RolloutStats, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := r.db.WithContext(ctx).Model(&entity.RolloutDevice{}).
+		Select("status, count(*) AS count").
+		Where("rollout_id = ?", id).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rollout devices: %w", err)
+	}
+
+	stats := &entity.RolloutStats{}
+	for _, row := range rows {
+		stats.Total += row.Count
+		switch row.Status {
+		case entity.OTAStatusPending:
+			continue
+		case entity.OTAStatusSucceeded:
+			stats.Succeeded += row.Count
+		case entity.OTAStatusFailed:
+			stats.Failed += row.Count
+		default:
+			stats.InProgress += row.Count
+		}
+		stats.Released += row.Count
+	}
+	return stats, nil
+}
+
+func (r *firmwareRepository) ListRolloutDevices(ctx context.Context, rolloutID uuid.UUID, stage *int) ([]*entity.RolloutDevice, error) {
+	var devices []*entity.RolloutDevice
+	query := r.db.WithContext(ctx).Where("rollout_id = ?", rolloutID).Order("stage asc, device_id asc")
+	if stage != nil {
+		query = query.Where("stage = ?", *stage)
+	}
+	if err := query.Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list rollout devices: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *firmwareRepository) GetRolloutDevice(ctx context.Context, rolloutID, deviceID uuid.UUID) (*entity.RolloutDevice, error) {
+	var device entity.RolloutDevice
+	if err := r.db.WithContext(ctx).Where("rollout_id = ? AND device_id = ?", rolloutID, deviceID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrRolloutNotFound
+		}
+		return nil, fmt.Errorf("failed to get rollout device: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *firmwareRepository) UpdateRolloutDevice(ctx context.Context, device *entity.RolloutDevice, from []string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.RolloutDevice{}).
+		Where("rollout_id = ? AND device_id = ? AND status IN ?", device.RolloutID, device.DeviceID, from).
+		Select("status", "progress", "error", "notified_at", "updated_at").
+		Updates(device)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update rollout device: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *firmwareRepository) ActiveRolloutDevices(ctx context.Context, deviceIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&entity.RolloutDevice{}).
+		Joins("JOIN firmware_rollouts ON firmware_rollouts.id = rollout_devices.rollout_id").
+		Where("firmware_rollouts.status IN ?", []string{entity.RolloutStatusRunning, entity.RolloutStatusHalted}).
+		Where("rollout_devices.device_id IN ?", deviceIDs).
+		Where("rollout_devices.status NOT IN ?", []string{entity.OTAStatusSucceeded, entity.OTAStatusFailed}).
+		Distinct().Pluck("rollout_devices.device_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices in active rollouts: %w", err)
+	}
+	return ids, nil
+}
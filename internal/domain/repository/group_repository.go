@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) iface.GroupRepository {
+	return &groupRepository{db: db}
+}
+
+func (r *groupRepository) Create(ctx context.Context, group *entity.DeviceGroup) error {
+	if err := r.db.WithContext(ctx).Omit("Devices").Create(group).Error; err != nil {
+		return fmt.Errorf("failed to create device group: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.DeviceGroup, error) {
+	var group entity.DeviceGroup
+	if err := r.db.WithContext(ctx).Preload("Devices").Where("id = ?", id).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to get device group by id: %w", err)
+	}
+	return &group, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, userID *uuid.UUID) ([]*entity.DeviceGroup, error) {
+	var groups []*entity.DeviceGroup
+	query := r.db.WithContext(ctx).Order("name asc")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to list device groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, group *entity.DeviceGroup) error {
+	if err := r.db.WithContext(ctx).Omit("Devices").Save(group).Error; err != nil {
+		return fmt.Errorf("failed to update device group: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.DeviceGroup{ID: id}).Association("Devices").Clear(); err != nil {
+			return err
+		}
+		// rule alert yang menargetkan group ikut dihapus beserta alert-nya
+		rules := tx.Model(&entity.AlertRule{}).Select("id").Where("group_id = ?", id)
+		if err := tx.Where("rule_id IN (?)", rules).Delete(&entity.Alert{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&entity.AlertRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.DeviceGroup{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete device group: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepository) AddDevices(ctx context.Context, groupID uuid.UUID, devices []*entity.Device) error {
+	if err := r.db.WithContext(ctx).Model(&entity.DeviceGroup{ID: groupID}).Omit("Devices.*").Association("Devices").Append(devices); err != nil {
+		return fmt.Errorf("failed to add devices to group: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepository) RemoveDevice(ctx context.Context, groupID, deviceID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Model(&entity.DeviceGroup{ID: groupID}).Association("Devices").Delete(&entity.Device{ID: deviceID}); err != nil {
+		return fmt.Errorf("failed to remove device from group: %w", err)
+	}
+	return nil
+}
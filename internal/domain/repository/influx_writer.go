@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"monitoring/config"
+	"monitoring/internal/domain/entity"
+	logger "monitoring/pkg/log"
+	"monitoring/pkg/spool"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	http2 "github.com/influxdata/influxdb-client-go/v2/api/http"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"go.uber.org/zap"
+)
+
+// writerStats dipublikasikan lewat expvar (/debug/vars) sebagai "influxdb_writer"
+var writerStats = expvar.NewMap("influxdb_writer")
+
+// influxWriter menyatukan dua mode penulisan: asinkron (batching dan retry oleh client InfluxDB)
+// untuk jalur MQTT, dan blocking dengan retry terbatas untuk jalur HTTP ingest.
+// Jika spool tersedia, batch asinkron yang gagal disimpan ke disk dan writer masuk mode degraded:
+// titik baru langsung ditulis ke spool sampai replayer berhasil mengosongkannya.
+type influxWriter struct {
+	client   influxdb2.Client
+	async    api.WriteAPI
+	blocking api.WriteAPIBlocking
+	log      *zap.Logger
+
+	maxRetries       int
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+
+	spool     *spool.Spool
+	degraded  atomic.Bool
+	batchSize int
+}
+
+func newInfluxWriter(client influxdb2.Client, cfg *config.InfluxDBConfig, telemetrySpool *spool.Spool) *influxWriter {
+	w := &influxWriter{
+		client:           client,
+		async:            client.WriteAPI(cfg.Org, cfg.Bucket),
+		blocking:         client.WriteAPIBlocking(cfg.Org, cfg.Bucket),
+		log:              logger.GetLogger().Named("influxdb_writer"),
+		maxRetries:       cfg.MaxRetries,
+		retryInterval:    cfg.RetryInterval,
+		maxRetryInterval: cfg.MaxRetryInterval,
+		spool:            telemetrySpool,
+		batchSize:        cfg.BatchSize,
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = 500
+	}
+	// sisa spool dari proses sebelumnya dikirim ulang dulu sebelum titik baru masuk ke client
+	if w.spool != nil && w.spool.Len() > 0 {
+		w.degraded.Store(true)
+	}
+
+	// Errors() harus dibaca sebelum penulisan pertama, kalau tidak error batch dibuang diam-diam
+	go w.consumeErrors(w.async.Errors())
+	w.async.SetWriteFailedCallback(w.onWriteFailed)
+	return w
+}
+
+// WriteAsync memasukkan titik ke buffer batch, hasil penulisan hanya terlihat di log dan expvar
+func (w *influxWriter) WriteAsync(point *write.Point) {
+	if w.spool != nil && w.degraded.Load() {
+		w.spoolLines(write.PointToLineProtocol(point, time.Nanosecond))
+		return
+	}
+	w.async.WritePoint(point)
+	writerStats.Add("points_queued", 1)
+}
+
+// WriteBlocking mengirim titik dalam satu request dan menunggu hasilnya. Error yang bisa dicoba ulang
+// (koneksi gagal, 429, 5xx) dicoba ulang paling banyak maxRetries kali dengan backoff eksponensial.
+func (w *influxWriter) WriteBlocking(ctx context.Context, points ...*write.Point) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = w.blocking.WritePoint(ctx, points...); err == nil {
+			writerStats.Add("points_written_sync", int64(len(points)))
+			return nil
+		}
+		if attempt >= w.maxRetries || !isRetryableWriteError(err) {
+			break
+		}
+
+		delay := w.backoff(attempt, err)
+		writerStats.Add("retries", 1)
+		w.log.Warn("retrying blocking write",
+			zap.Int("points", len(points)),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+		break
+	}
+
+	writerStats.Add("sync_failures", 1)
+	w.log.Error("blocking write failed", zap.Int("points", len(points)), zap.Error(err))
+	return err
+}
+
+// backoff menghitung jeda retry berikutnya, Retry-After dari server diutamakan
+func (w *influxWriter) backoff(attempt int, err error) time.Duration {
+	var httpErr *http2.Error
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return time.Duration(httpErr.RetryAfter) * time.Second
+	}
+	delay := w.retryInterval << attempt
+	if delay <= 0 || delay > w.maxRetryInterval {
+		delay = w.maxRetryInterval
+	}
+	return delay
+}
+
+func isRetryableWriteError(err error) bool {
+	var httpErr *http2.Error
+	if !errors.As(err, &httpErr) {
+		// error di luar HTTP (misalnya encoding titik) tidak akan berhasil jika diulang
+		return false
+	}
+	return httpErr.StatusCode == 0 || httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+}
+
+// onWriteFailed dipanggil client untuk setiap batch asinkron yang gagal dengan error yang bisa dicoba ulang,
+// dan setelah maxRetries kali batch dibuang oleh client
+func (w *influxWriter) onWriteFailed(batch string, err http2.Error, retryAttempts uint) bool {
+	points := strings.Count(batch, "\n") + 1
+	if w.spool != nil {
+		// spool menggantikan retry di memori, replayer yang mengirim ulang setelah InfluxDB sehat
+		w.degraded.Store(true)
+		w.log.Warn("async batch write failed, spooling to disk",
+			zap.Int("points", points),
+			zap.Int("status", err.StatusCode),
+			zap.Error(&err))
+		w.spoolLines(strings.Split(strings.TrimSpace(batch), "\n")...)
+		return false
+	}
+	if retryAttempts >= uint(w.maxRetries) {
+		writerStats.Add("points_dropped", int64(points))
+		w.log.Error("async batch dropped after max retries",
+			zap.Int("points", points),
+			zap.Int("status", err.StatusCode),
+			zap.Uint("attempts", retryAttempts+1),
+			zap.Error(&err))
+		return true
+	}
+
+	writerStats.Add("retries", 1)
+	w.log.Warn("async batch write failed, retrying",
+		zap.Int("points", points),
+		zap.Int("status", err.StatusCode),
+		zap.Uint("attempt", retryAttempts+1),
+		zap.Error(&err))
+	return true
+}
+
+func (w *influxWriter) spoolLines(lines ...string) {
+	records := make([][]byte, len(lines))
+	for i, line := range lines {
+		records[i] = []byte(line)
+	}
+	dropped, err := w.spool.Append(records...)
+	if err != nil {
+		writerStats.Add("points_dropped", int64(len(lines)))
+		w.log.Error("failed to spool points", zap.Int("points", len(lines)), zap.Error(err))
+		return
+	}
+	writerStats.Add("points_spooled", int64(len(lines)))
+	if dropped > 0 {
+		writerStats.Add("points_dropped", int64(dropped))
+		w.log.Error("spool size limit reached, oldest segment dropped", zap.Int("points", dropped))
+	}
+}
+
+// Replay mengirim ulang isi spool berurutan jika InfluxDB sudah sehat. Setelah segment tertutup habis,
+// writer keluar dari mode degraded lalu segment aktif ditutup dan ikut dikirim.
+func (w *influxWriter) Replay(ctx context.Context) (int, error) {
+	if w.spool == nil || (!w.degraded.Load() && w.spool.Len() == 0) {
+		return 0, nil
+	}
+	if ok, err := w.client.Ping(ctx); !ok {
+		return 0, fmt.Errorf("influxdb is not ready: %v", err)
+	}
+
+	replayed, err := w.spool.Replay(w.batchSize, func(records [][]byte) error {
+		return w.replayRecords(ctx, records)
+	})
+	if err != nil {
+		return replayed, err
+	}
+
+	w.degraded.Store(false)
+	if err := w.spool.Rotate(); err != nil {
+		return replayed, err
+	}
+	n, err := w.spool.Replay(w.batchSize, func(records [][]byte) error {
+		return w.replayRecords(ctx, records)
+	})
+	replayed += n
+	if replayed > 0 {
+		w.log.Info("spool replayed", zap.Int("points", replayed))
+	}
+	return replayed, err
+}
+
+// replayRecords menulis satu potongan spool, potongan yang ditolak InfluxDB (bukan error sementara)
+// dibuang agar tidak memblokir antrean selamanya
+func (w *influxWriter) replayRecords(ctx context.Context, records [][]byte) error {
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = string(record)
+	}
+	if err := w.blocking.WriteRecord(ctx, lines...); err != nil {
+		if isRetryableWriteError(err) {
+			return err
+		}
+		writerStats.Add("points_dropped", int64(len(lines)))
+		w.log.Error("dropping spooled points rejected by influxdb", zap.Int("points", len(lines)), zap.Error(err))
+		return nil
+	}
+	writerStats.Add("points_replayed", int64(len(lines)))
+	return nil
+}
+
+func (w *influxWriter) SpoolStats() *entity.SpoolStats {
+	stats := &entity.SpoolStats{Enabled: w.spool != nil, Degraded: w.degraded.Load()}
+	if w.spool == nil {
+		return stats
+	}
+	s := w.spool.Stats()
+	stats.Segments = s.Segments
+	stats.Records = s.Records
+	stats.Bytes = s.Bytes
+	stats.MaxBytes = s.MaxBytes
+	stats.DroppedRecords = s.DroppedRecords
+	if !s.Oldest.IsZero() {
+		stats.OldestAt = &s.Oldest
+	}
+	return stats
+}
+
+func (w *influxWriter) consumeErrors(errs <-chan error) {
+	for err := range errs {
+		writerStats.Add("write_errors", 1)
+		w.log.Error("async write failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type metricRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricRepository(db *gorm.DB) iface.MetricRepository {
+	return &metricRepository{db: db}
+}
+
+func (r *metricRepository) Create(ctx context.Context, def *entity.MetricDefinition) error {
+	if err := r.db.WithContext(ctx).Create(def).Error; err != nil {
+		return fmt.Errorf("failed to create metric definition: %w", err)
+	}
+	return nil
+}
+
+func (r *metricRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.MetricDefinition, error) {
+	var def entity.MetricDefinition
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&def).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrMetricNotFound
+		}
+		return nil, fmt.Errorf("failed to get metric definition by id: %w", err)
+	}
+	return &def, nil
+}
+
+func (r *metricRepository) List(ctx context.Context, deviceType string) ([]*entity.MetricDefinition, error) {
+	var defs []*entity.MetricDefinition
+	query := r.db.WithContext(ctx).Order("device_type asc, name asc")
+	if deviceType != "" {
+		query = query.Where("device_type = ?", deviceType)
+	}
+	if err := query.Find(&defs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list metric definitions: %w", err)
+	}
+	return defs, nil
+}
+
+func (r *metricRepository) ListByName(ctx context.Context, name string) ([]*entity.MetricDefinition, error) {
+	var defs []*entity.MetricDefinition
+	if err := r.db.WithContext(ctx).Where("name = ?", name).Find(&defs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list metric definitions by name: %w", err)
+	}
+	return defs, nil
+}
+
+func (r *metricRepository) Update(ctx context.Context, def *entity.MetricDefinition) error {
+	if err := r.db.WithContext(ctx).Save(def).Error; err != nil {
+		return fmt.Errorf("failed to update metric definition: %w", err)
+	}
+	return nil
+}
+
+func (r *metricRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&entity.MetricDefinition{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete metric definition: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"monitoring/config"
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/spool"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,47 +18,89 @@ import (
 
 type monitoringRepository struct {
 	client   influxdb2.Client
-	writeAPI api.WriteAPI
+	writer   *influxWriter
 	queryAPI api.QueryAPI
 	bucket   string
 	org      string
 }
 
-func NewMonitoringRepository(client influxdb2.Client, cfg *config.InfluxDBConfig) iface.MonitoringRepository {
+// telemetrySpool boleh nil, artinya batch asinkron yang gagal hanya dicoba ulang di memori
+func NewMonitoringRepository(client influxdb2.Client, cfg *config.InfluxDBConfig, telemetrySpool *spool.Spool) iface.MonitoringRepository {
 	return &monitoringRepository{
 		client:   client,
-		writeAPI: client.WriteAPI(cfg.Org, cfg.Bucket),
+		writer:   newInfluxWriter(client, cfg, telemetrySpool),
 		queryAPI: client.QueryAPI(cfg.Org),
 		bucket:   cfg.Bucket,
 		org:      cfg.Org,
 	}
 }
 
-func (r *monitoringRepository) Store(ctx context.Context, data *entity.MonitoringData) error {
-	point := write.NewPoint("device_monitoring",
-		map[string]string{
-			"device_id": data.DeviceID.String(),
-		},
-		map[string]interface{}{
-			"cpu_usage":    data.CPUUsage,
-			"memory_usage": data.MemoryUsage,
-			"disk_usage":   data.DiskUsage,
-			"temperature":  data.Temperature,
-		},
-		data.Timestamp)
+// groupTagKey adalah tag InfluxDB penanda keanggotaan group, nilainya selalu "1"
+func groupTagKey(groupID uuid.UUID) string {
+	return "group_" + groupID.String()
+}
 
-	r.writeAPI.WritePoint(point)
-	r.writeAPI.Flush()
+// Store memasukkan titik ke batch asinkron, gagal tulis dilaporkan lewat log dan expvar
+func (r *monitoringRepository) Store(ctx context.Context, data *entity.MonitoringData) error {
+	r.writer.WriteAsync(newMonitoringPoint(data))
+	return nil
+}
 
+// StoreBatch menulis semua titik dalam satu request blocking, error dikembalikan ke pemanggil
+func (r *monitoringRepository) StoreBatch(ctx context.Context, data []*entity.MonitoringData) error {
+	points := make([]*write.Point, len(data))
+	for i, item := range data {
+		points[i] = newMonitoringPoint(item)
+	}
+	if err := r.writer.WriteBlocking(ctx, points...); err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrTelemetryWrite, err)
+	}
 	return nil
 }
 
+// ReplaySpool mengirim ulang titik yang tertahan di spool disk, mengembalikan jumlah titik yang terkirim
+func (r *monitoringRepository) ReplaySpool(ctx context.Context) (int, error) {
+	return r.writer.Replay(ctx)
+}
+
+func (r *monitoringRepository) SpoolStats() *entity.SpoolStats {
+	return r.writer.SpoolStats()
+}
+
+func newMonitoringPoint(data *entity.MonitoringData) *write.Point {
+	tags := map[string]string{
+		"device_id": data.DeviceID.String(),
+	}
+	if data.Meta != nil {
+		for key, value := range data.Meta.Tags {
+			tags["tag_"+key] = value
+		}
+		for _, groupID := range data.Meta.GroupIDs {
+			tags[groupTagKey(groupID)] = "1"
+		}
+	}
+
+	fields := map[string]interface{}{
+		"cpu_usage":    data.CPUUsage,
+		"memory_usage": data.MemoryUsage,
+		"disk_usage":   data.DiskUsage,
+		"temperature":  data.Temperature,
+	}
+	for name, value := range data.Fields {
+		fields[name] = value
+	}
+
+	return write.NewPoint("device_monitoring", tags, fields, data.Timestamp)
+}
+
 func (r *monitoringRepository) GetByDeviceID(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error) {
 	query := fmt.Sprintf(`
         from(bucket: "%s")
             |> range(start: %s, stop: %s)
             |> filter(fn: (r) => r._measurement == "device_monitoring")
             |> filter(fn: (r) => r.device_id == "%s")
+            |> group(columns: ["device_id", "_field"])
+            |> sort(columns: ["_time"])
             |> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
             |> limit(n: %d)
     `, r.bucket, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), deviceID.String(), limit)
@@ -75,37 +119,126 @@ func (r *monitoringRepository) GetByDeviceID(ctx context.Context, deviceID uuid.
 			Timestamp: record.Time(),
 		}
 
-		if cpu, ok := record.ValueByKey("cpu_usage").(float64); ok {
-			data.CPUUsage = cpu
-		}
-		if memory, ok := record.ValueByKey("memory_usage").(float64); ok {
-			data.MemoryUsage = memory
+		fillMetrics(data, record.Values())
+
+		monitoringData = append(monitoringData, data)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("query error: %w", result.Err())
+	}
+
+	return monitoringData, nil
+}
+
+func (r *monitoringRepository) GetSeries(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string) ([]*entity.MonitoringData, error) {
+	query := fmt.Sprintf(`
+        from(bucket: "%s")
+            |> range(start: %s, stop: %s)
+            |> filter(fn: (r) => r._measurement == "device_monitoring")
+            |> filter(fn: (r) => r.device_id == "%s")
+            |> filter(fn: (r) => %s)
+            |> group(columns: ["device_id", "_field"])
+            |> aggregateWindow(every: %ds, fn: %s, createEmpty: false)
+            |> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+    `, r.bucket, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), deviceID.String(), fieldFilter(metrics), int64(every.Seconds()), fn)
+
+	result, err := r.queryAPI.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query monitoring series: %w", err)
+	}
+	defer result.Close()
+
+	points := []*entity.MonitoringData{}
+	for result.Next() {
+		record := result.Record()
+		data := &entity.MonitoringData{
+			DeviceID:  deviceID,
+			Timestamp: record.Time(),
 		}
-		if disk, ok := record.ValueByKey("disk_usage").(float64); ok {
-			data.DiskUsage = disk
+
+		fillMetrics(data, record.Values())
+
+		points = append(points, data)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("query error: %w", result.Err())
+	}
+
+	return points, nil
+}
+
+func (r *monitoringRepository) GetGroupSeries(ctx context.Context, groupID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string, perDevice bool) ([]*entity.MonitoringData, error) {
+	// Series dikelompokkan ulang per device_id agar perubahan tag di tengah rentang tidak memecah series.
+	// Agregasi lintas perangkat: last tidak bermakna antar perangkat sehingga diganti mean
+	fleetFn := fn
+	if fleetFn == "last" {
+		fleetFn = "mean"
+	}
+	fleetStage := fmt.Sprintf(`
+            |> group(columns: ["_field", "_time"])
+            |> %s()
+            |> group(columns: ["_field"])`, fleetFn)
+	if perDevice {
+		fleetStage = ""
+	}
+
+	query := fmt.Sprintf(`
+        from(bucket: "%s")
+            |> range(start: %s, stop: %s)
+            |> filter(fn: (r) => r._measurement == "device_monitoring")
+            |> filter(fn: (r) => r["%s"] == "1")
+            |> filter(fn: (r) => %s)
+            |> group(columns: ["device_id", "_field"])
+            |> aggregateWindow(every: %ds, fn: %s, createEmpty: false)%s
+            |> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+            |> sort(columns: ["_time"])
+    `, r.bucket, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), groupTagKey(groupID), fieldFilter(metrics), int64(every.Seconds()), fn, fleetStage)
+
+	result, err := r.queryAPI.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group series: %w", err)
+	}
+	defer result.Close()
+
+	points := []*entity.MonitoringData{}
+	for result.Next() {
+		record := result.Record()
+		data := &entity.MonitoringData{
+			Timestamp: record.Time(),
 		}
-		if temp, ok := record.ValueByKey("temperature").(float64); ok {
-			data.Temperature = temp
+		if perDevice {
+			if deviceID, ok := record.ValueByKey("device_id").(string); ok {
+				data.DeviceID, _ = uuid.Parse(deviceID)
+			}
 		}
 
-		monitoringData = append(monitoringData, data)
+		fillMetrics(data, record.Values())
+
+		points = append(points, data)
 	}
 
 	if result.Err() != nil {
 		return nil, fmt.Errorf("query error: %w", result.Err())
 	}
 
-	return monitoringData, nil
+	return points, nil
 }
 
 func (r *monitoringRepository) GetLatestByDeviceID(ctx context.Context, deviceID uuid.UUID) (*entity.MonitoringData, error) {
+	// last() pertama berjalan per series (tag/group berbeda), hasilnya dikelompokkan ulang
+	// per field lalu diambil yang terbaru agar series lama tidak ikut terpilih
 	query := fmt.Sprintf(`
         from(bucket: "%s")
-            |> range(start: -1h)
+            |> range(start: 0)
             |> filter(fn: (r) => r._measurement == "device_monitoring")
             |> filter(fn: (r) => r.device_id == "%s")
-            |> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
             |> last()
+            |> group(columns: ["device_id", "_field"])
+            |> sort(columns: ["_time"])
+            |> last()
+            |> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
     `, r.bucket, deviceID.String())
 
 	result, err := r.queryAPI.Query(ctx, query)
@@ -121,37 +254,36 @@ func (r *monitoringRepository) GetLatestByDeviceID(ctx context.Context, deviceID
 			Timestamp: record.Time(),
 		}
 
-		if cpu, ok := record.ValueByKey("cpu_usage").(float64); ok {
-			data.CPUUsage = cpu
-		}
-		if memory, ok := record.ValueByKey("memory_usage").(float64); ok {
-			data.MemoryUsage = memory
-		}
-		if disk, ok := record.ValueByKey("disk_usage").(float64); ok {
-			data.DiskUsage = disk
-		}
-		if temp, ok := record.ValueByKey("temperature").(float64); ok {
-			data.Temperature = temp
-		}
+		fillMetrics(data, record.Values())
 
 		return data, nil
 	}
 
-	return nil, fmt.Errorf("no data found")
+	if result.Err() != nil {
+		return nil, fmt.Errorf("query error: %w", result.Err())
+	}
+
+	return nil, entity.ErrNoTelemetry
 }
 
-func (r *monitoringRepository) GetStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time) (*entity.MonitoringStats, error) {
+func (r *monitoringRepository) GetStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, metrics []string) (*entity.MonitoringStats, error) {
+	// Setiap statistik di-yield dengan nama sendiri sehingga cukup satu round-trip ke InfluxDB.
+	// Series dikelompokkan ulang per field agar perubahan tag/group tidak memecah statistik.
 	query := fmt.Sprintf(`
-        from(bucket: "%s")
+        data = from(bucket: "%s")
             |> range(start: %s, stop: %s)
             |> filter(fn: (r) => r._measurement == "device_monitoring")
             |> filter(fn: (r) => r.device_id == "%s")
-            |> group(columns: ["_field"])
-            |> aggregateWindow(every: 1h, fn: mean, createEmpty: false)
-            |> yield(name: "mean")
-    `, r.bucket, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), deviceID.String())
+            |> filter(fn: (r) => %s)
+            |> group(columns: ["device_id", "_field"])
+
+        data |> min() |> yield(name: "min")
+        data |> max() |> yield(name: "max")
+        data |> mean() |> yield(name: "mean")
+        data |> quantile(q: 0.95, method: "exact_mean") |> yield(name: "p95")
+        data |> count() |> yield(name: "count")
+    `, r.bucket, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), deviceID.String(), fieldFilter(metrics))
 
-	// This is a simplified version - you might want to implement more detailed statistics
 	result, err := r.queryAPI.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query monitoring stats: %w", err)
@@ -160,18 +292,133 @@ func (r *monitoringRepository) GetStats(ctx context.Context, deviceID uuid.UUID,
 
 	stats := &entity.MonitoringStats{
 		DeviceID: deviceID,
-		Period:   fmt.Sprintf("%s to %s", startTime.Format("2006-01-02"), endTime.Format("2006-01-02")),
+		Period:   fmt.Sprintf("%s to %s", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339)),
+		Metrics:  make(map[string]*entity.MetricStats, len(metrics)),
+	}
+	for _, name := range metrics {
+		stats.Metrics[name] = &entity.MetricStats{}
 	}
 
-	dataPoints := 0
 	for result.Next() {
-		dataPoints++
+		record := result.Record()
+		metric, ok := stats.Metrics[record.Field()]
+		if !ok {
+			continue
+		}
+
+		if record.Result() == "count" {
+			if count, ok := record.Value().(int64); ok {
+				metric.Count = int(count)
+				if int(count) > stats.DataPoints {
+					stats.DataPoints = int(count)
+				}
+			}
+			continue
+		}
+
+		value, ok := record.Value().(float64)
+		if !ok {
+			continue
+		}
+		switch record.Result() {
+		case "min":
+			metric.Min = value
+		case "max":
+			metric.Max = value
+		case "mean":
+			metric.Mean = value
+		case "p95":
+			metric.P95 = value
+		}
+		if target := statsField(stats, record.Result(), record.Field()); target != nil {
+			*target = value
+		}
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("query error: %w", result.Err())
 	}
-	stats.DataPoints = dataPoints
 
 	return stats, nil
 }
 
+// fieldFilter membangun predikat Flux untuk daftar field, nama metric sudah divalidasi usecase
+func fieldFilter(metrics []string) string {
+	if len(metrics) == 0 {
+		metrics = entity.DefaultMetricNames()
+	}
+	conditions := make([]string, len(metrics))
+	for i, name := range metrics {
+		conditions[i] = fmt.Sprintf(`r._field == "%s"`, name)
+	}
+	return strings.Join(conditions, " or ")
+}
+
+// fillMetrics mengisi empat metric bawaan ke field MonitoringData dan kolom field lain hasil pivot ke Fields
+func fillMetrics(data *entity.MonitoringData, values map[string]interface{}) {
+	for key, value := range values {
+		if value == nil || !isFieldColumn(key) {
+			continue
+		}
+		number, _ := value.(float64)
+		switch key {
+		case "cpu_usage":
+			data.CPUUsage = number
+		case "memory_usage":
+			data.MemoryUsage = number
+		case "disk_usage":
+			data.DiskUsage = number
+		case "temperature":
+			data.Temperature = number
+		default:
+			if data.Fields == nil {
+				data.Fields = make(map[string]interface{})
+			}
+			data.Fields[key] = value
+		}
+	}
+}
+
+// isFieldColumn membedakan kolom field hasil pivot dari kolom internal Flux dan tag
+func isFieldColumn(key string) bool {
+	switch key {
+	case "result", "table", "device_id":
+		return false
+	}
+	return !strings.HasPrefix(key, "_") && !strings.HasPrefix(key, "tag_") && !strings.HasPrefix(key, "group_")
+}
+
+// statsField mengembalikan pointer ke field MonitoringStats untuk kombinasi statistik dan metric
+func statsField(stats *entity.MonitoringStats, stat, field string) *float64 {
+	fields := map[string]map[string]*float64{
+		"min": {
+			"cpu_usage":    &stats.MinCPU,
+			"memory_usage": &stats.MinMemory,
+			"disk_usage":   &stats.MinDisk,
+			"temperature":  &stats.MinTemp,
+		},
+		"max": {
+			"cpu_usage":    &stats.MaxCPU,
+			"memory_usage": &stats.MaxMemory,
+			"disk_usage":   &stats.MaxDisk,
+			"temperature":  &stats.MaxTemp,
+		},
+		"mean": {
+			"cpu_usage":    &stats.AvgCPU,
+			"memory_usage": &stats.AvgMemory,
+			"disk_usage":   &stats.AvgDisk,
+			"temperature":  &stats.AvgTemp,
+		},
+		"p95": {
+			"cpu_usage":    &stats.P95CPU,
+			"memory_usage": &stats.P95Memory,
+			"disk_usage":   &stats.P95Disk,
+			"temperature":  &stats.P95Temp,
+		},
+	}
+	return fields[stat][field]
+}
+
 func (r *monitoringRepository) DeleteOldData(ctx context.Context, retentionPeriod time.Duration) error {
 	deleteTime := time.Now().Add(-retentionPeriod)
 
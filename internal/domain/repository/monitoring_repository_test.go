@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/domain/entity"
+
+	"github.com/google/uuid"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// fluxStub mencatat query Flux yang dikirim repository dan membalas annotated CSV yang sudah disiapkan.
+// Nilai statistik dihitung oleh InfluxDB, jadi test memeriksa query yang dibangun dan pemetaan hasilnya.
+func fluxStub(t *testing.T, response string) (*httptest.Server, *string) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/query" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode query: %v", err)
+			return
+		}
+		query = body.Query
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &query
+}
+
+// statsCSV membangun satu tabel per hasil yield dengan baris per field
+func statsCSV(deviceID uuid.UUID, rows map[string]map[string]string) string {
+	var b strings.Builder
+	for _, result := range []string{"min", "max", "mean", "p95", "count"} {
+		valueType := "double"
+		if result == "count" {
+			valueType = "long"
+		}
+		fmt.Fprintf(&b, "#datatype,string,long,string,string,%s\n", valueType)
+		fmt.Fprintf(&b, "#group,false,false,true,true,false\n")
+		fmt.Fprintf(&b, "#default,%s,,,,\n", result)
+		fmt.Fprintf(&b, ",result,table,_field,device_id,_value\n")
+		table := 0
+		for _, field := range []string{"cpu_usage", "temperature", "fan_rpm"} {
+			if value, ok := rows[result][field]; ok {
+				fmt.Fprintf(&b, ",,%d,%s,%s,%s\n", table, field, deviceID, value)
+				table++
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// compactFlux menghapus spasi agar assertion tidak bergantung pada indentasi query
+func compactFlux(query string) string {
+	return regexp.MustCompile(`\s+`).ReplaceAllString(query, "")
+}
+
+func TestGetStatsQuery(t *testing.T) {
+	deviceID := uuid.New()
+	server, query := fluxStub(t, statsCSV(deviceID, nil))
+
+	client := influxdb2.NewClient(server.URL, "token")
+	defer client.Close()
+	repo := NewMonitoringRepository(client, &config.InfluxDBConfig{Org: "org", Bucket: "telemetry"}, nil)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	if _, err := repo.GetStats(context.Background(), deviceID, start, end, []string{"cpu_usage", "fan_rpm"}); err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+
+	flux := compactFlux(*query)
+	split := strings.Index(flux, `data|>min()`)
+	if split < 0 {
+		t.Fatalf("query has no min stage: %s", *query)
+	}
+	source, yields := flux[:split], flux[split:]
+	for _, stage := range []string{
+		`data=from(bucket:"telemetry")`,
+		`|>range(start:2026-01-01T00:00:00Z,stop:2026-01-02T00:00:00Z)`,
+		`|>filter(fn:(r)=>r._measurement=="device_monitoring")`,
+		fmt.Sprintf(`|>filter(fn:(r)=>r.device_id=="%s")`, deviceID),
+		`|>filter(fn:(r)=>r._field=="cpu_usage"orr._field=="fan_rpm")`,
+		// tanpa group ulang, series yang tag-nya berubah di tengah rentang menghasilkan statistik terpisah
+		`|>group(columns:["device_id","_field"])`,
+	} {
+		if !strings.Contains(source, stage) {
+			t.Errorf("source stream missing %s\nquery: %s", stage, *query)
+		}
+	}
+
+	wantYields := `data|>min()|>yield(name:"min")` +
+		`data|>max()|>yield(name:"max")` +
+		`data|>mean()|>yield(name:"mean")` +
+		`data|>quantile(q:0.95,method:"exact_mean")|>yield(name:"p95")` +
+		`data|>count()|>yield(name:"count")`
+	if yields != wantYields {
+		t.Errorf("yields:\n got %s\nwant %s", yields, wantYields)
+	}
+}
+
+func TestGetStatsMapsResults(t *testing.T) {
+	deviceID := uuid.New()
+	server, _ := fluxStub(t, statsCSV(deviceID, map[string]map[string]string{
+		"min":   {"cpu_usage": "10", "temperature": "41", "fan_rpm": "1200"},
+		"max":   {"cpu_usage": "100", "temperature": "51", "fan_rpm": "2400"},
+		"mean":  {"cpu_usage": "55", "temperature": "46", "fan_rpm": "1800"},
+		"p95":   {"cpu_usage": "95.5", "temperature": "50", "fan_rpm": "2340"},
+		"count": {"cpu_usage": "10", "temperature": "6", "fan_rpm": "3"},
+	}))
+
+	client := influxdb2.NewClient(server.URL, "token")
+	defer client.Close()
+	repo := NewMonitoringRepository(client, &config.InfluxDBConfig{Org: "org", Bucket: "telemetry"}, nil)
+
+	end := time.Now()
+	stats, err := repo.GetStats(context.Background(), deviceID, end.Add(-time.Hour), end, []string{"cpu_usage", "temperature", "fan_rpm"})
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+
+	want := map[string]entity.MetricStats{
+		"cpu_usage":   {Min: 10, Max: 100, Mean: 55, P95: 95.5, Count: 10},
+		"temperature": {Min: 41, Max: 51, Mean: 46, P95: 50, Count: 6},
+		"fan_rpm":     {Min: 1200, Max: 2400, Mean: 1800, P95: 2340, Count: 3},
+	}
+	for name, expected := range want {
+		got, ok := stats.Metrics[name]
+		if !ok {
+			t.Fatalf("missing stats for %s", name)
+		}
+		if *got != expected {
+			t.Errorf("%s: got %+v, want %+v", name, *got, expected)
+		}
+	}
+
+	if stats.MinCPU != 10 || stats.AvgCPU != 55 || stats.MaxCPU != 100 || stats.P95CPU != 95.5 {
+		t.Errorf("legacy cpu fields: min=%v avg=%v max=%v p95=%v", stats.MinCPU, stats.AvgCPU, stats.MaxCPU, stats.P95CPU)
+	}
+	if stats.MinTemp != 41 || stats.AvgTemp != 46 || stats.MaxTemp != 51 || stats.P95Temp != 50 {
+		t.Errorf("legacy temperature fields: min=%v avg=%v max=%v p95=%v", stats.MinTemp, stats.AvgTemp, stats.MaxTemp, stats.P95Temp)
+	}
+	if stats.DataPoints != 10 {
+		t.Errorf("data points: got %d, want 10", stats.DataPoints)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) iface.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) CreateChannel(ctx context.Context, channel *entity.NotificationChannel) error {
+	if err := r.db.WithContext(ctx).Create(channel).Error; err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetChannelByID(ctx context.Context, id uuid.UUID) (*entity.NotificationChannel, error) {
+	var channel entity.NotificationChannel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrChannelNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification channel by id: %w", err)
+	}
+	return &channel, nil
+}
+
+func (r *notificationRepository) ListChannels(ctx context.Context, userID *uuid.UUID) ([]*entity.NotificationChannel, error) {
+	var channels []*entity.NotificationChannel
+	query := r.db.WithContext(ctx).Order("created_at desc")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (r *notificationRepository) ListEnabledChannels(ctx context.Context, userID uuid.UUID) ([]*entity.NotificationChannel, error) {
+	var channels []*entity.NotificationChannel
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND enabled = ?", userID, true).Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list enabled notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (r *notificationRepository) UpdateChannel(ctx context.Context, channel *entity.NotificationChannel) error {
+	if err := r.db.WithContext(ctx).Save(channel).Error; err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) DeleteChannel(ctx context.Context, id uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", id).Delete(&entity.NotificationDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.NotificationChannel{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) CreateDelivery(ctx context.Context, delivery *entity.NotificationDelivery) error {
+	if err := r.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create notification delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) ListDeliveries(ctx context.Context, channelID uuid.UUID, limit, offset int) ([]*entity.NotificationDelivery, error) {
+	var deliveries []*entity.NotificationDelivery
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Order("created_at desc").
+		Limit(limit).Offset(offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type shadowRepository struct {
+	db *gorm.DB
+}
+
+func NewShadowRepository(db *gorm.DB) iface.ShadowRepository {
+	return &shadowRepository{db: db}
+}
+
+func (r *shadowRepository) Get(ctx context.Context, deviceID uuid.UUID) (*entity.DeviceShadow, error) {
+	var shadow entity.DeviceShadow
+	if err := r.db.WithContext(ctx).Where("device_id = ?", deviceID).First(&shadow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &entity.DeviceShadow{DeviceID: deviceID}, nil
+		}
+		return nil, fmt.Errorf("failed to get device shadow: %w", err)
+	}
+	return &shadow, nil
+}
+
+func (r *shadowRepository) Update(ctx context.Context, deviceID uuid.UUID, fn func(shadow *entity.DeviceShadow) error) (*entity.DeviceShadow, error) {
+	var shadow entity.DeviceShadow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// baris dibuat lebih dulu agar selalu ada yang bisa dikunci, termasuk saat dua update pertama bersamaan
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.DeviceShadow{DeviceID: deviceID}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("device_id = ?", deviceID).First(&shadow).Error; err != nil {
+			return err
+		}
+		if err := fn(&shadow); err != nil {
+			return err
+		}
+		return tx.Save(&shadow).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update device shadow: %w", err)
+	}
+	return &shadow, nil
+}
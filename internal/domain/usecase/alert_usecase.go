@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type alertUsecase struct {
+	alertRepo  iface.AlertRepository
+	deviceRepo iface.DeviceRepository
+	groupRepo  iface.GroupRepository
+	publisher  iface.EventPublisher
+}
+
+func NewAlertUsecase(alertRepo iface.AlertRepository, deviceRepo iface.DeviceRepository, groupRepo iface.GroupRepository, publisher iface.EventPublisher) iface.AlertUseCase {
+	return &alertUsecase{
+		alertRepo:  alertRepo,
+		deviceRepo: deviceRepo,
+		groupRepo:  groupRepo,
+		publisher:  publisher,
+	}
+}
+
+func (a *alertUsecase) CreateRule(ctx context.Context, requester *entity.Requester, req *entity.AlertRuleRequest) (*entity.AlertRule, error) {
+	rule := &entity.AlertRule{
+		UserID:  requester.UserID,
+		Enabled: true,
+	}
+	if err := a.applyRuleRequest(ctx, requester, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := a.alertRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (a *alertUsecase) GetRule(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.AlertRule, error) {
+	rule, err := a.alertRepo.GetRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccess(rule.UserID) {
+		return nil, entity.ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+func (a *alertUsecase) ListRules(ctx context.Context, requester *entity.Requester) ([]*entity.AlertRule, error) {
+	if requester.IsAdmin() {
+		return a.alertRepo.ListRules(ctx, nil)
+	}
+	return a.alertRepo.ListRules(ctx, &requester.UserID)
+}
+
+func (a *alertUsecase) UpdateRule(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.AlertRuleRequest) (*entity.AlertRule, error) {
+	rule, err := a.GetRule(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.applyRuleRequest(ctx, requester, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := a.alertRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (a *alertUsecase) DeleteRule(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	if _, err := a.GetRule(ctx, requester, id); err != nil {
+		return err
+	}
+	return a.alertRepo.DeleteRule(ctx, id)
+}
+
+func (a *alertUsecase) ListAlerts(ctx context.Context, requester *entity.Requester, query *entity.AlertQuery) ([]*entity.Alert, error) {
+	if query.Limit <= 0 {
+		query.Limit = 50 // default limit
+	}
+	query.UserID = nil
+	if !requester.IsAdmin() {
+		query.UserID = &requester.UserID
+	}
+	return a.alertRepo.ListAlerts(ctx, query)
+}
+
+// applyRuleRequest menyalin request ke rule dan memastikan device_id atau group_id (jika ada) dimiliki requester
+func (a *alertUsecase) applyRuleRequest(ctx context.Context, requester *entity.Requester, rule *entity.AlertRule, req *entity.AlertRuleRequest) error {
+	// metric tambahan dievaluasi dari MonitoringData.Fields, hanya nilai number yang bisa dibandingkan
+	if !entity.ValidMetricName(req.Metric) {
+		return fmt.Errorf("%w: nama metric %q tidak valid", entity.ErrInvalidRequest, req.Metric)
+	}
+	if req.DeviceID != nil {
+		device, err := a.deviceRepo.GetByID(ctx, *req.DeviceID)
+		if err != nil {
+			return err
+		}
+		if !requester.CanAccessDevice(device) {
+			return entity.ErrDeviceNotFound
+		}
+	}
+	if req.GroupID != nil {
+		group, err := a.groupRepo.GetByID(ctx, *req.GroupID)
+		if err != nil {
+			return err
+		}
+		if !requester.CanAccess(group.UserID) {
+			return entity.ErrGroupNotFound
+		}
+	}
+
+	rule.Name = req.Name
+	rule.Metric = req.Metric
+	rule.Operator = req.Operator
+	rule.Threshold = *req.Threshold
+	rule.Duration = req.Duration
+	rule.Severity = req.Severity
+	if rule.Severity == "" {
+		rule.Severity = "warning"
+	}
+	rule.DeviceID = req.DeviceID
+	rule.GroupID = req.GroupID
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// Evaluate menjalankan state machine pending -> firing -> resolved untuk setiap rule yang berlaku.
+// Kondisi terpenuhi membuat alert pending, yang naik menjadi firing setelah bertahan selama rule.Duration.
+// Kondisi tidak terpenuhi membatalkan alert pending dan me-resolve alert firing.
+func (a *alertUsecase) Evaluate(ctx context.Context, data *entity.MonitoringData) error {
+	rules, err := a.alertRepo.ListRulesForDevice(ctx, data.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		value, ok := data.Metric(rule.Metric)
+		if !ok {
+			continue
+		}
+		if err := a.evaluateRule(ctx, rule, data, value); err != nil {
+			log.Printf("Failed to evaluate alert rule %s for device %s: %v", rule.ID, data.DeviceID, err)
+		}
+	}
+	return nil
+}
+
+func (a *alertUsecase) evaluateRule(ctx context.Context, rule *entity.AlertRule, data *entity.MonitoringData, value float64) error {
+	alert, err := a.alertRepo.GetActiveAlert(ctx, rule.ID, data.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	now := data.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// reading backfill yang lebih lama dari perubahan state terakhir tidak boleh menggerakkan state machine
+	if alert != nil && now.Before(alert.LastStateChange()) {
+		return nil
+	}
+
+	if !rule.Matches(value) {
+		if alert == nil {
+			return nil
+		}
+		if alert.State == entity.AlertStatePending {
+			return a.alertRepo.DeleteAlert(ctx, alert.ID)
+		}
+		alert.State = entity.AlertStateResolved
+		alert.Value = value
+		alert.ResolvedAt = &now
+		if err := a.alertRepo.UpdateAlert(ctx, alert); err != nil {
+			return err
+		}
+		a.publish(ctx, entity.EventAlertResolved, rule, alert)
+		return nil
+	}
+
+	if alert == nil {
+		resolvedAt, err := a.alertRepo.GetLastResolvedAt(ctx, rule.ID, data.DeviceID)
+		if err != nil {
+			return err
+		}
+		if resolvedAt != nil && now.Before(*resolvedAt) {
+			return nil
+		}
+		device, err := a.deviceRepo.GetByID(ctx, data.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to load device: %w", err)
+		}
+		alert = &entity.Alert{
+			RuleID:    rule.ID,
+			DeviceID:  data.DeviceID,
+			UserID:    device.UserID,
+			State:     entity.AlertStatePending,
+			Metric:    rule.Metric,
+			Severity:  rule.Severity,
+			Threshold: rule.Threshold,
+			Value:     value,
+			StartedAt: now,
+		}
+		if rule.Duration == 0 {
+			alert.State = entity.AlertStateFiring
+			alert.FiredAt = &now
+		}
+		if err := a.alertRepo.CreateAlert(ctx, alert); err != nil {
+			return err
+		}
+		if alert.State == entity.AlertStateFiring {
+			a.publish(ctx, entity.EventAlertFiring, rule, alert)
+		}
+		return nil
+	}
+
+	alert.Value = value
+	fired := false
+	if alert.State == entity.AlertStatePending && now.Sub(alert.StartedAt) >= time.Duration(rule.Duration)*time.Second {
+		alert.State = entity.AlertStateFiring
+		alert.FiredAt = &now
+		fired = true
+	}
+	if err := a.alertRepo.UpdateAlert(ctx, alert); err != nil {
+		return err
+	}
+	if fired {
+		a.publish(ctx, entity.EventAlertFiring, rule, alert)
+	}
+	return nil
+}
+
+func (a *alertUsecase) publish(ctx context.Context, eventType string, rule *entity.AlertRule, alert *entity.Alert) {
+	alert.Rule = rule
+	a.publisher.Publish(ctx, &entity.Event{
+		Type:       eventType,
+		UserID:     alert.UserID,
+		DeviceID:   &alert.DeviceID,
+		Data:       alert,
+		OccurredAt: alert.UpdatedAt,
+	})
+}
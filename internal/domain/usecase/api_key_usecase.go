@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix = "mk_"
+	// lastUsedResolution membatasi seberapa sering last_used_at ditulis untuk key yang sama
+	lastUsedResolution = time.Minute
+)
+
+type apiKeyUsecase struct {
+	apiKeyRepo iface.APIKeyRepository
+	deviceRepo iface.DeviceRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo iface.APIKeyRepository, deviceRepo iface.DeviceRepository) iface.APIKeyUseCase {
+	return &apiKeyUsecase{
+		apiKeyRepo: apiKeyRepo,
+		deviceRepo: deviceRepo,
+	}
+}
+
+func (a *apiKeyUsecase) Create(ctx context.Context, requester *entity.Requester, req *entity.APIKeyRequest) (*entity.APIKeyCreated, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at harus di masa depan", entity.ErrInvalidRequest)
+	}
+	for _, id := range req.DeviceIDs {
+		device, err := a.deviceRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !requester.CanAccessDevice(device) {
+			return nil, entity.ErrDeviceNotFound
+		}
+	}
+
+	raw, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &entity.APIKey{
+		UserID:    requester.UserID,
+		Name:      req.Name,
+		Prefix:    raw[:len(apiKeyPrefix)+8],
+		KeyHash:   utils.HashSHA256(raw),
+		Scopes:    req.Scopes,
+		DeviceIDs: req.DeviceIDs,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := a.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &entity.APIKeyCreated{APIKey: key, Key: raw}, nil
+}
+
+func (a *apiKeyUsecase) List(ctx context.Context, requester *entity.Requester) ([]*entity.APIKey, error) {
+	return a.apiKeyRepo.ListByUserID(ctx, requester.UserID)
+}
+
+func (a *apiKeyUsecase) Revoke(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	key, err := a.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !requester.CanAccess(key.UserID) {
+		return entity.ErrAPIKeyNotFound
+	}
+	return a.apiKeyRepo.Revoke(ctx, id, time.Now())
+}
+
+func (a *apiKeyUsecase) Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, entity.ErrInvalidAPIKey
+	}
+
+	key, err := a.apiKeyRepo.GetByHash(ctx, utils.HashSHA256(rawKey))
+	if err != nil {
+		if errors.Is(err, entity.ErrAPIKeyNotFound) {
+			return nil, entity.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) || key.Owner == nil {
+		return nil, entity.ErrInvalidAPIKey
+	}
+
+	if err := a.apiKeyRepo.TouchLastUsed(ctx, key.ID, now, now.Add(-lastUsedResolution)); err != nil {
+		log.Printf("Failed to update last used for api key %s: %v", key.ID, err)
+	}
+	return key, nil
+}
+
+// generateAPIKey membuat key acak 32 byte dengan prefix agar mudah dikenali (misalnya oleh secret scanner)
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
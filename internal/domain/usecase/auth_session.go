@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"monitoring/internal/domain/entity"
+	"monitoring/pkg/jwt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Layout Redis:
+//
+//	session:<sid>          JSON entity.Session, TTL = umur refresh token
+//	user_sessions:<userID> SET berisi sid milik user
+//	denylist:<jti>         access token yang dicabut, TTL = sisa umur token
+//	refresh_rotated:<jti>  refresh token yang sudah ditukar, TTL = sisa umur token
+func sessionKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return "user_sessions:" + userID.String()
+}
+
+func denylistKey(jti string) string {
+	return "denylist:" + jti
+}
+
+// issueTokens menerbitkan pasangan token baru untuk sesi dan menyimpan jti-nya.
+// Access token sebelumnya dari sesi yang sama langsung dicabut sehingga tiap sesi hanya punya satu access token aktif.
+func (u *authUsecase) issueTokens(ctx context.Context, user *entity.User, session *entity.Session) (string, string, error) {
+	accessClaims := &jwt.Claims{
+		UserID:    user.ID.String(),
+		Role:      user.Role,
+		SessionID: session.ID,
+		TokenType: jwt.TokenTypeAccess,
+	}
+	accessToken, err := u.jwtService.GenerateToken(accessClaims, u.jwtService.AccessTokenDuration())
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshClaims := &jwt.Claims{
+		UserID:    user.ID.String(),
+		Role:      user.Role,
+		SessionID: session.ID,
+		TokenType: jwt.TokenTypeRefresh,
+	}
+	refreshToken, err := u.jwtService.GenerateToken(refreshClaims, u.jwtService.RefreshTokenDuration())
+	if err != nil {
+		return "", "", err
+	}
+
+	if session.AccessJTI != "" {
+		if err := u.denyToken(ctx, session.AccessJTI, session.AccessExpiresAt); err != nil {
+			return "", "", err
+		}
+	}
+
+	now := time.Now()
+	session.AccessJTI = accessClaims.ID
+	session.AccessExpiresAt = accessClaims.ExpiresAt.Time
+	session.RefreshJTI = refreshClaims.ID
+	session.Generation++
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(u.jwtService.RefreshTokenDuration())
+
+	if err := u.saveSession(ctx, session); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func rotatedKey(jti string) string {
+	return "refresh_rotated:" + jti
+}
+
+func (u *authUsecase) Logout(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	return u.RevokeSession(ctx, userID, sessionID)
+}
+
+func (u *authUsecase) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	sessionIDs, err := u.cache0.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := u.RevokeSession(ctx, userID, sessionID); err != nil && !errors.Is(err, entity.ErrSessionNotFound) {
+			return err
+		}
+	}
+	return u.cache0.Del(ctx, userSessionsKey(userID))
+}
+
+func (u *authUsecase) ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]*entity.SessionInfo, error) {
+	sessionIDs, err := u.cache0.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*entity.SessionInfo{}
+	for _, sessionID := range sessionIDs {
+		session, err := u.getSession(ctx, sessionID)
+		if err != nil {
+			// sesi sudah kedaluwarsa, bersihkan dari set
+			u.cache0.SRem(ctx, userSessionsKey(userID), sessionID)
+			continue
+		}
+		sessions = append(sessions, &entity.SessionInfo{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession menghapus sesi (refresh token tidak bisa dipakai lagi) dan mencabut access token aktifnya
+func (u *authUsecase) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	session, err := u.getSession(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return entity.ErrSessionNotFound
+	}
+
+	if err := u.denyToken(ctx, session.AccessJTI, session.AccessExpiresAt); err != nil {
+		return err
+	}
+	if err := u.cache0.Del(ctx, sessionKey(sessionID)); err != nil {
+		return err
+	}
+	return u.cache0.SRem(ctx, userSessionsKey(userID), sessionID).Err()
+}
+
+func (u *authUsecase) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := u.cache0.Exists(ctx, denylistKey(jti))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (u *authUsecase) denyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return u.cache0.Set(ctx, denylistKey(jti), "1", ttl)
+}
+
+func (u *authUsecase) getSession(ctx context.Context, sessionID string) (*entity.Session, error) {
+	if sessionID == "" {
+		return nil, entity.ErrSessionNotFound
+	}
+	raw, err := u.cache0.Get(ctx, sessionKey(sessionID))
+	if err != nil {
+		return nil, entity.ErrSessionNotFound
+	}
+
+	var session entity.Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (u *authUsecase) saveSession(ctx context.Context, session *entity.Session) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := u.cache0.Set(ctx, sessionKey(session.ID), payload, u.jwtService.RefreshTokenDuration()); err != nil {
+		return err
+	}
+
+	setKey := userSessionsKey(session.UserID)
+	if err := u.cache0.SAdd(ctx, setKey, session.ID).Err(); err != nil {
+		return err
+	}
+	return u.cache0.Expire(ctx, setKey, u.jwtService.RefreshTokenDuration()).Err()
+}
+
+// markRotated mencatat refresh token sebagai sudah dipakai, false berarti token sudah pernah dirotasi
+func (u *authUsecase) markRotated(ctx context.Context, claims *jwt.Claims) (bool, error) {
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return false, nil
+	}
+	return u.cache0.SetNX(ctx, rotatedKey(claims.ID), claims.SessionID, ttl).Result()
+}
+
+// unmarkRotated membatalkan markRotated saat rotasi gagal sebelum token baru tersimpan
+func (u *authUsecase) unmarkRotated(ctx context.Context, claims *jwt.Claims) {
+	if err := u.cache0.Del(ctx, rotatedKey(claims.ID)); err != nil {
+		log.Printf("Failed to roll back rotation mark for jti %s: %v", claims.ID, err)
+	}
+}
+
+// handleTokenReuse mencabut seluruh family (session beserta access token aktifnya)
+// dan mengirim event security.token_reuse ke channel notifikasi user
+func (u *authUsecase) handleTokenReuse(ctx context.Context, session *entity.Session, claims *jwt.Claims, meta *entity.SessionMeta) {
+	log.Printf("Refresh token reuse detected for user %s session %s (jti %s, ip %s)", session.UserID, session.ID, claims.ID, meta.IPAddress)
+
+	if err := u.RevokeSession(ctx, session.UserID, session.ID); err != nil && !errors.Is(err, entity.ErrSessionNotFound) {
+		log.Printf("Failed to revoke session %s after token reuse: %v", session.ID, err)
+	}
+
+	u.publisher.Publish(ctx, &entity.Event{
+		Type:   entity.EventTokenReuse,
+		UserID: session.UserID,
+		Data: &entity.TokenReuseEvent{
+			SessionID:  session.ID,
+			TokenID:    claims.ID,
+			Generation: session.Generation,
+			UserAgent:  meta.UserAgent,
+			IPAddress:  meta.IPAddress,
+		},
+	})
+}
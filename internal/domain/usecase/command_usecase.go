@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultCommandTimeout = 60 * time.Second
+
+type commandUsecase struct {
+	commandRepo iface.CommandRepository
+	deviceRepo  iface.DeviceRepository
+	publisher   iface.CommandPublisher
+}
+
+func NewCommandUsecase(commandRepo iface.CommandRepository, deviceRepo iface.DeviceRepository, publisher iface.CommandPublisher) iface.CommandUseCase {
+	return &commandUsecase{
+		commandRepo: commandRepo,
+		deviceRepo:  deviceRepo,
+		publisher:   publisher,
+	}
+}
+
+// Send menyimpan perintah dengan status queued lalu mempublikasikannya ke perangkat
+func (c *commandUsecase) Send(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, req *entity.CommandRequest) (*entity.DeviceCommand, error) {
+	if err := c.ensureDeviceAccess(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultCommandTimeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	now := time.Now()
+	command := &entity.DeviceCommand{
+		DeviceID:  deviceID,
+		UserID:    requester.UserID,
+		Command:   req.Command,
+		Params:    req.Params,
+		Status:    entity.CommandStatusQueued,
+		ExpiresAt: now.Add(timeout),
+	}
+	if err := c.commandRepo.Create(ctx, command); err != nil {
+		return nil, err
+	}
+
+	msg := &entity.CommandMessage{
+		CorrelationID: command.ID,
+		Command:       command.Command,
+		Params:        command.Params,
+		IssuedAt:      now,
+		ExpiresAt:     command.ExpiresAt,
+	}
+
+	update := *command
+	if err := c.publisher.PublishCommand(deviceID, msg); err != nil {
+		completedAt := time.Now()
+		update.Status = entity.CommandStatusFailed
+		update.Error = fmt.Sprintf("failed to publish command: %v", err)
+		update.CompletedAt = &completedAt
+	} else {
+		sentAt := time.Now()
+		update.Status = entity.CommandStatusSent
+		update.SentAt = &sentAt
+	}
+
+	changed, err := c.commandRepo.Transition(ctx, &update, []string{entity.CommandStatusQueued})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		// perangkat sudah membalas sebelum status sent tersimpan
+		return c.commandRepo.GetByID(ctx, command.ID)
+	}
+	return &update, nil
+}
+
+func (c *commandUsecase) Get(ctx context.Context, requester *entity.Requester, deviceID, id uuid.UUID) (*entity.DeviceCommand, error) {
+	if err := c.ensureDeviceAccess(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
+
+	command, err := c.commandRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if command.DeviceID != deviceID {
+		return nil, entity.ErrCommandNotFound
+	}
+	return command, nil
+}
+
+func (c *commandUsecase) List(ctx context.Context, requester *entity.Requester, query *entity.CommandQuery) ([]*entity.DeviceCommand, error) {
+	if err := c.ensureDeviceAccess(ctx, requester, query.DeviceID); err != nil {
+		return nil, err
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 50 // default limit
+	}
+	return c.commandRepo.List(ctx, query)
+}
+
+func (c *commandUsecase) HandleResponse(ctx context.Context, deviceID uuid.UUID, resp *entity.CommandResponse) error {
+	command, err := c.commandRepo.GetByID(ctx, resp.CorrelationID)
+	if err != nil {
+		return err
+	}
+	// perangkat hanya boleh membalas perintah miliknya sendiri
+	if command.DeviceID != deviceID {
+		return entity.ErrCommandNotFound
+	}
+
+	now := time.Now()
+	var from []string
+	switch resp.Status {
+	case entity.CommandStatusAcked:
+		from = []string{entity.CommandStatusQueued, entity.CommandStatusSent}
+		command.AckedAt = &now
+	case entity.CommandStatusSucceeded, entity.CommandStatusFailed:
+		from = entity.CommandPendingStatuses
+		if command.AckedAt == nil {
+			command.AckedAt = &now
+		}
+		command.CompletedAt = &now
+		command.Result = resp.Result
+		command.Error = resp.Error
+	default:
+		return fmt.Errorf("%w: unknown command status %q", entity.ErrInvalidRequest, resp.Status)
+	}
+	command.Status = resp.Status
+
+	changed, err := c.commandRepo.Transition(ctx, command, from)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Printf("Ignoring %s response for command %s, command already past that status", resp.Status, command.ID)
+	}
+	return nil
+}
+
+func (c *commandUsecase) StartTimeoutSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sweepExpired(ctx)
+		}
+	}
+}
+
+func (c *commandUsecase) sweepExpired(ctx context.Context) {
+	commands, err := c.commandRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to list expired commands: %v", err)
+		return
+	}
+
+	for _, command := range commands {
+		now := time.Now()
+		command.Status = entity.CommandStatusTimedOut
+		command.Error = "no response from device before expires_at"
+		command.CompletedAt = &now
+		if _, err := c.commandRepo.Transition(ctx, command, entity.CommandPendingStatuses); err != nil {
+			log.Printf("Failed to mark command %s timed out: %v", command.ID, err)
+		}
+	}
+}
+
+func (c *commandUsecase) ensureDeviceAccess(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) error {
+	device, err := c.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if !requester.CanAccessDevice(device) {
+		return entity.ErrDeviceNotFound
+	}
+	return nil
+}
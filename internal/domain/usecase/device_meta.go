@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// deviceMetaTTL membatasi umur cache tipe/tag/group perangkat, perubahan lewat API juga langsung menghapus cache
+const deviceMetaTTL = 5 * time.Minute
+
+func deviceMetaKey(deviceID uuid.UUID) string {
+	return "device_meta:" + deviceID.String()
+}
+
+// loadDeviceMeta mengambil tipe, tag dan group perangkat dari cache Redis, fallback ke database.
+// Gagal memuat metadata tidak menggagalkan penulisan telemetry, titik hanya ditulis tanpa tag tambahan.
+func loadDeviceMeta(ctx context.Context, cache *db.Client, deviceRepo iface.DeviceRepository, deviceID uuid.UUID) *entity.DeviceMeta {
+	if cached, err := cache.Get(ctx, deviceMetaKey(deviceID)); err == nil {
+		var meta entity.DeviceMeta
+		if err := json.Unmarshal([]byte(cached), &meta); err == nil {
+			return &meta
+		}
+	}
+
+	device, err := deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		log.Printf("Failed to load device metadata for %s: %v", deviceID, err)
+		return nil
+	}
+
+	meta := &entity.DeviceMeta{Type: device.Type, Tags: device.Tags}
+	for _, group := range device.Groups {
+		meta.GroupIDs = append(meta.GroupIDs, group.ID)
+	}
+	if payload, err := json.Marshal(meta); err == nil {
+		cache.Set(ctx, deviceMetaKey(deviceID), payload, deviceMetaTTL)
+	}
+	return meta
+}
+
+func invalidateDeviceMeta(ctx context.Context, cache *db.Client, deviceIDs ...uuid.UUID) {
+	for _, id := range deviceIDs {
+		if err := cache.Del(ctx, deviceMetaKey(id)); err != nil {
+			log.Printf("Failed to invalidate device metadata for %s: %v", id, err)
+		}
+	}
+}
@@ -2,27 +2,51 @@ package usecase
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
 	"monitoring/pkg/db"
+	"monitoring/pkg/utils"
+	"net"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const deviceTokenPrefix = "dt_"
+
 type deviceUsecase struct {
 	deviceRepo iface.DeviceRepository
 	cache0     *db.Client
+	publisher  iface.EventPublisher
 }
 
-func NewDeviceUsecase(deviceRepo iface.DeviceRepository, cache *db.Client) iface.DeviceUseCase {
+func NewDeviceUsecase(deviceRepo iface.DeviceRepository, cache *db.Client, publisher iface.EventPublisher) iface.DeviceUseCase {
 	return &deviceUsecase{
 		deviceRepo: deviceRepo,
 		cache0:     cache,
+		publisher:  publisher,
 	}
 }
 
-func (d *deviceUsecase) Create(ctx context.Context, device *entity.Device) error {
-	return d.deviceRepo.Create(ctx, device)
+func (d *deviceUsecase) Create(ctx context.Context, device *entity.Device) (string, error) {
+	if err := d.claimMacAddress(ctx, device, device.MacAddress); err != nil {
+		return "", err
+	}
+	token, hash, err := generateDeviceToken()
+	if err != nil {
+		return "", err
+	}
+	device.TokenHash = &hash
+	if err := d.deviceRepo.Create(ctx, device); err != nil {
+		return "", err
+	}
+	return token, nil
 }
 
 func (d *deviceUsecase) GetByID(ctx context.Context, id uuid.UUID) (*entity.Device, error) {
@@ -53,4 +77,219 @@ func (d *deviceUsecase) List(ctx context.Context, limit, offset int) ([]*entity.
     return d.deviceRepo.List(ctx, limit, offset)
 }
 
+// ListOwned mengembalikan perangkat milik requester, admin dapat melihat semua perangkat
+func (d *deviceUsecase) ListOwned(ctx context.Context, requester *entity.Requester, query *entity.DeviceListQuery) (*entity.DeviceList, error) {
+	if query.Limit <= 0 {
+		query.Limit = 20 // default limit
+	}
+	query.UserID = nil
+	if !requester.IsAdmin() {
+		query.UserID = &requester.UserID
+	}
+	query.IDs = requester.DeviceIDs
+	return d.deviceRepo.ListFiltered(ctx, query)
+}
+
+// GetOwned mengambil perangkat berdasarkan id dan memastikan requester adalah pemiliknya.
+// Perangkat milik tenant lain dilaporkan sebagai tidak ditemukan agar keberadaannya tidak bocor.
+func (d *deviceUsecase) GetOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.Device, error) {
+	device, err := d.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccessDevice(device) {
+		return nil, entity.ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+// claimMacAddress menormalkan MAC ke format yang sama dengan provisioning dan menolak MAC milik perangkat lain
+func (d *deviceUsecase) claimMacAddress(ctx context.Context, device *entity.Device, macAddress string) error {
+	mac, err := net.ParseMAC(macAddress)
+	if err != nil {
+		return fmt.Errorf("%w: mac_address tidak valid", entity.ErrInvalidRequest)
+	}
+	existing, err := d.deviceRepo.GetByMacAddress(ctx, mac.String())
+	if err == nil && existing.ID != device.ID {
+		return fmt.Errorf("%w: %s", entity.ErrDeviceExists, mac)
+	}
+	if err != nil && !errors.Is(err, entity.ErrDeviceNotFound) {
+		return err
+	}
+	device.MacAddress = mac.String()
+	return nil
+}
+
+// UpdateOwned menerapkan partial update, hanya field yang dikirim yang diubah
+func (d *deviceUsecase) UpdateOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.DeviceUpdateRequest) (*entity.Device, error) {
+	device, err := d.GetOwned(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
 
+	if req.Name != nil {
+		device.Name = *req.Name
+	}
+	if req.Type != nil {
+		device.Type = *req.Type
+	}
+	if req.MacAddress != nil {
+		if err := d.claimMacAddress(ctx, device, *req.MacAddress); err != nil {
+			return nil, err
+		}
+	}
+	if req.IPAddress != nil {
+		device.IPAddress = *req.IPAddress
+	}
+	if req.Location != nil {
+		device.Location = *req.Location
+	}
+	if req.Tags != nil {
+		device.Tags = req.Tags
+	}
+
+	if err := d.deviceRepo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+	if req.Tags != nil || req.Type != nil {
+		invalidateDeviceMeta(ctx, d.cache0, device.ID)
+	}
+	return device, nil
+}
+
+func (d *deviceUsecase) DeleteOwned(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	if _, err := d.GetOwned(ctx, requester, id); err != nil {
+		return err
+	}
+	if err := d.deviceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	invalidateDeviceMeta(ctx, d.cache0, id)
+	if err := d.cache0.Del(ctx, latestKey(id)); err != nil {
+		log.Printf("Failed to delete latest data for %s: %v", id, err)
+	}
+	return nil
+}
+
+func (d *deviceUsecase) RotateToken(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.DeviceCredential, error) {
+	device, err := d.GetOwned(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, hash, err := generateDeviceToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.deviceRepo.SetTokenHash(ctx, device.ID, hash); err != nil {
+		return nil, err
+	}
+	device.TokenHash = &hash
+	return &entity.DeviceCredential{Device: device, Token: token}, nil
+}
+
+func (d *deviceUsecase) AuthenticateToken(ctx context.Context, token string) (*entity.Device, error) {
+	if !strings.HasPrefix(token, deviceTokenPrefix) {
+		return nil, entity.ErrInvalidDevice
+	}
+	device, err := d.deviceRepo.GetByTokenHash(ctx, utils.HashSHA256(token))
+	if err != nil {
+		if errors.Is(err, entity.ErrDeviceNotFound) {
+			return nil, entity.ErrInvalidDevice
+		}
+		return nil, err
+	}
+	return device, nil
+}
+
+// generateDeviceToken menghasilkan token acak beserta hash yang disimpan di database
+func generateDeviceToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = deviceTokenPrefix + hex.EncodeToString(buf)
+	return token, utils.HashSHA256(token), nil
+}
+
+func (d *deviceUsecase) Heartbeat(ctx context.Context, deviceID uuid.UUID) error {
+	cameOnline, err := d.deviceRepo.Touch(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if cameOnline {
+		d.publishStatus(ctx, deviceID, entity.EventDeviceOnline, "telemetry")
+	}
+	return nil
+}
+
+func (d *deviceUsecase) ReportStatus(ctx context.Context, deviceID uuid.UUID, isOnline bool) error {
+	changed, err := d.deviceRepo.SetOnline(ctx, deviceID, isOnline)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	eventType := entity.EventDeviceOffline
+	if isOnline {
+		eventType = entity.EventDeviceOnline
+	}
+	d.publishStatus(ctx, deviceID, eventType, "status_topic")
+	return nil
+}
+
+func (d *deviceUsecase) StartOfflineSweeper(ctx context.Context, interval, offlineAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.sweepOffline(ctx, offlineAfter)
+		}
+	}
+}
+
+func (d *deviceUsecase) sweepOffline(ctx context.Context, offlineAfter time.Duration) {
+	devices, err := d.deviceRepo.ListStaleOnline(ctx, time.Now().Add(-offlineAfter))
+	if err != nil {
+		log.Printf("Failed to list stale devices: %v", err)
+		return
+	}
+
+	for _, device := range devices {
+		changed, err := d.deviceRepo.SetOnline(ctx, device.ID, false)
+		if err != nil {
+			log.Printf("Failed to mark device %s offline: %v", device.ID, err)
+			continue
+		}
+		if changed {
+			d.publishStatus(ctx, device.ID, entity.EventDeviceOffline, "timeout")
+		}
+	}
+}
+
+// publishStatus menerbitkan event transisi online/offline, reason berisi sumber transisi
+func (d *deviceUsecase) publishStatus(ctx context.Context, deviceID uuid.UUID, eventType, reason string) {
+	device, err := d.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		log.Printf("Failed to load device %s for status event: %v", deviceID, err)
+		return
+	}
+	device.User = nil
+
+	log.Printf("Device %s is now %s (%s)", deviceID, eventType, reason)
+	d.publisher.Publish(ctx, &entity.Event{
+		Type:     eventType,
+		UserID:   device.UserID,
+		DeviceID: &device.ID,
+		Data: map[string]interface{}{
+			"device": device,
+			"reason": reason,
+		},
+	})
+}
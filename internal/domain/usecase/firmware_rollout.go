@@ -0,0 +1,429 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"math"
+	"monitoring/internal/domain/entity"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultFailureThreshold = 20.0
+
+func (f *firmwareUsecase) CreateRollout(ctx context.Context, requester *entity.Requester, req *entity.RolloutRequest) (*entity.FirmwareRollout, error) {
+	firmware, err := f.Get(ctx, requester, req.FirmwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := req.Stages
+	if len(stages) == 0 {
+		stages = []int{100}
+	}
+	for i, pct := range stages {
+		if i > 0 && pct <= stages[i-1] {
+			return nil, fmt.Errorf("%w: stages harus naik, contoh [10, 50, 100]", entity.ErrInvalidRequest)
+		}
+	}
+	if stages[len(stages)-1] != 100 {
+		return nil, fmt.Errorf("%w: stage terakhir harus 100", entity.ErrInvalidRequest)
+	}
+
+	threshold := defaultFailureThreshold
+	if req.FailureThreshold != nil {
+		threshold = *req.FailureThreshold
+	}
+
+	targets, err := f.resolveTargets(ctx, requester, firmware, req)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := f.firmwareRepo.ActiveRolloutDevices(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		return nil, fmt.Errorf("%w: %d perangkat masih ikut rollout lain, contoh %s", entity.ErrInvalidRequest, len(active), active[0])
+	}
+
+	rollout := &entity.FirmwareRollout{
+		ID:               uuid.New(),
+		UserID:           firmware.UserID,
+		FirmwareID:       firmware.ID,
+		Status:           entity.RolloutStatusRunning,
+		Stages:           stages,
+		FailureThreshold: threshold,
+		DeviceIDs:        req.DeviceIDs,
+		GroupIDs:         req.GroupIDs,
+	}
+	if err := f.firmwareRepo.CreateRollout(ctx, rollout, assignStages(rollout.ID, targets, stages)); err != nil {
+		return nil, err
+	}
+
+	rollout.Firmware = firmware
+	f.releaseStage(ctx, rollout, 0)
+	f.evaluateRollout(ctx, rollout.ID, true)
+	return f.GetRollout(ctx, requester, rollout.ID)
+}
+
+func (f *firmwareUsecase) GetRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error) {
+	rollout, err := f.firmwareRepo.GetRollout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccess(rollout.UserID) {
+		return nil, entity.ErrRolloutNotFound
+	}
+
+	if rollout.Stats, err = f.firmwareRepo.RolloutStats(ctx, id); err != nil {
+		return nil, err
+	}
+	if rollout.Devices, err = f.firmwareRepo.ListRolloutDevices(ctx, id, nil); err != nil {
+		return nil, err
+	}
+	return rollout, nil
+}
+
+func (f *firmwareUsecase) ListRollouts(ctx context.Context, requester *entity.Requester) ([]*entity.FirmwareRollout, error) {
+	if requester.IsAdmin() {
+		return f.firmwareRepo.ListRollouts(ctx, nil)
+	}
+	return f.firmwareRepo.ListRollouts(ctx, &requester.UserID)
+}
+
+// HaltRollout menghentikan perilisan stage berikutnya, perangkat yang sudah menerima notifikasi tetap melanjutkan update
+func (f *firmwareUsecase) HaltRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error) {
+	rollout, err := f.GetRollout(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rollout.Status = entity.RolloutStatusHalted
+	rollout.HaltReason = "halted by user"
+	changed, err := f.firmwareRepo.SetRolloutStatus(ctx, rollout, []string{entity.RolloutStatusRunning})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: hanya rollout running yang bisa dihentikan", entity.ErrInvalidRequest)
+	}
+	return f.GetRollout(ctx, requester, id)
+}
+
+// ResumeRollout melanjutkan rollout yang dihentikan. Hitungan gagal tidak direset, sehingga
+// kegagalan berikutnya langsung menghentikan rollout lagi jika rasio masih di atas threshold.
+func (f *firmwareUsecase) ResumeRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error) {
+	rollout, err := f.GetRollout(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rollout.Status = entity.RolloutStatusRunning
+	rollout.HaltReason = ""
+	changed, err := f.firmwareRepo.SetRolloutStatus(ctx, rollout, []string{entity.RolloutStatusHalted})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: hanya rollout halted yang bisa dilanjutkan", entity.ErrInvalidRequest)
+	}
+
+	f.evaluateRollout(ctx, id, false)
+	return f.GetRollout(ctx, requester, id)
+}
+
+func (f *firmwareUsecase) CancelRollout(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.FirmwareRollout, error) {
+	rollout, err := f.GetRollout(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rollout.Status = entity.RolloutStatusCancelled
+	rollout.CompletedAt = &now
+	changed, err := f.firmwareRepo.SetRolloutStatus(ctx, rollout, []string{entity.RolloutStatusRunning, entity.RolloutStatusHalted})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: rollout sudah selesai", entity.ErrInvalidRequest)
+	}
+
+	// tarik notifikasi retained agar perangkat yang belum mulai tidak ikut update
+	for _, device := range rollout.Devices {
+		if device.Status == entity.OTAStatusNotified {
+			f.clearNotification(device.DeviceID)
+		}
+	}
+	return f.GetRollout(ctx, requester, id)
+}
+
+func (f *firmwareUsecase) ReportProgress(ctx context.Context, deviceID uuid.UUID, progress *entity.OTAProgress) error {
+	switch progress.Status {
+	case entity.OTAStatusDownloading, entity.OTAStatusInstalling, entity.OTAStatusSucceeded, entity.OTAStatusFailed:
+	default:
+		return fmt.Errorf("%w: unknown ota status %q", entity.ErrInvalidRequest, progress.Status)
+	}
+
+	device, err := f.firmwareRepo.GetRolloutDevice(ctx, progress.RolloutID, deviceID)
+	if err != nil {
+		return err
+	}
+	rollout, err := f.firmwareRepo.GetRollout(ctx, progress.RolloutID)
+	if err != nil {
+		return err
+	}
+	if rollout.Status == entity.RolloutStatusCancelled {
+		return nil
+	}
+
+	device.Status = progress.Status
+	device.Progress = min(max(progress.Progress, 0), 100)
+	device.Error = progress.Error
+	if progress.Status == entity.OTAStatusSucceeded {
+		device.Progress = 100
+	}
+	changed, err := f.firmwareRepo.UpdateRolloutDevice(ctx, device, entity.OTAInProgressStatuses)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	switch progress.Status {
+	case entity.OTAStatusSucceeded:
+		f.clearNotification(deviceID)
+		f.recordFirmwareVersion(ctx, deviceID, rollout.Firmware)
+		f.evaluateRollout(ctx, rollout.ID, true)
+	case entity.OTAStatusFailed:
+		f.clearNotification(deviceID)
+		f.evaluateRollout(ctx, rollout.ID, true)
+	}
+	return nil
+}
+
+// evaluateRollout menghentikan rollout jika rasio gagal melewati threshold, lalu merilis stage
+// berikutnya atau menandai selesai jika semua perangkat yang dirilis sudah selesai. Stage tanpa
+// perangkat (target sedikit) langsung dilewati. checkFailures false hanya dipakai saat resume,
+// agar kegagalan yang sudah diketahui operator tidak langsung menghentikan rollout lagi.
+func (f *firmwareUsecase) evaluateRollout(ctx context.Context, id uuid.UUID, checkFailures bool) {
+	for {
+		rollout, err := f.firmwareRepo.GetRollout(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load rollout %s: %v", id, err)
+			return
+		}
+		if rollout.Status != entity.RolloutStatusRunning {
+			return
+		}
+		stats, err := f.firmwareRepo.RolloutStats(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load rollout %s stats: %v", id, err)
+			return
+		}
+
+		if checkFailures && stats.Released > 0 {
+			rate := float64(stats.Failed) * 100 / float64(stats.Released)
+			if rate > rollout.FailureThreshold {
+				f.haltRollout(ctx, rollout, fmt.Sprintf("failure rate %.1f%% exceeded threshold %.1f%%", rate, rollout.FailureThreshold))
+				return
+			}
+		}
+		if stats.InProgress > 0 {
+			return
+		}
+
+		if rollout.CurrentStage >= len(rollout.Stages)-1 {
+			now := time.Now()
+			rollout.Status = entity.RolloutStatusCompleted
+			rollout.CompletedAt = &now
+			changed, err := f.firmwareRepo.SetRolloutStatus(ctx, rollout, []string{entity.RolloutStatusRunning})
+			if err != nil {
+				log.Printf("Failed to complete rollout %s: %v", id, err)
+				return
+			}
+			if changed {
+				f.publishRolloutEvent(ctx, entity.EventRolloutDone, rollout, stats)
+			}
+			return
+		}
+
+		advanced, err := f.firmwareRepo.AdvanceStage(ctx, id, rollout.CurrentStage)
+		if err != nil {
+			log.Printf("Failed to advance rollout %s: %v", id, err)
+			return
+		}
+		if !advanced {
+			return // sudah dinaikkan oleh laporan perangkat lain
+		}
+		f.releaseStage(ctx, rollout, rollout.CurrentStage+1)
+		checkFailures = true
+	}
+}
+
+func (f *firmwareUsecase) haltRollout(ctx context.Context, rollout *entity.FirmwareRollout, reason string) {
+	rollout.Status = entity.RolloutStatusHalted
+	rollout.HaltReason = reason
+	changed, err := f.firmwareRepo.SetRolloutStatus(ctx, rollout, []string{entity.RolloutStatusRunning})
+	if err != nil {
+		log.Printf("Failed to halt rollout %s: %v", rollout.ID, err)
+		return
+	}
+	if !changed {
+		return
+	}
+
+	log.Printf("Rollout %s halted: %s", rollout.ID, reason)
+	stats, err := f.firmwareRepo.RolloutStats(ctx, rollout.ID)
+	if err != nil {
+		log.Printf("Failed to load rollout %s stats: %v", rollout.ID, err)
+	}
+	f.publishRolloutEvent(ctx, entity.EventRolloutHalted, rollout, stats)
+}
+
+// releaseStage mengirim notifikasi OTA ke semua perangkat pending di stage
+func (f *firmwareUsecase) releaseStage(ctx context.Context, rollout *entity.FirmwareRollout, stage int) {
+	devices, err := f.firmwareRepo.ListRolloutDevices(ctx, rollout.ID, &stage)
+	if err != nil {
+		log.Printf("Failed to list devices of rollout %s stage %d: %v", rollout.ID, stage, err)
+		return
+	}
+
+	firmware := rollout.Firmware
+	notification := &entity.OTANotification{
+		RolloutID:  rollout.ID,
+		FirmwareID: firmware.ID,
+		Version:    firmware.Version,
+		URL:        fmt.Sprintf("%s/api/v1/ota/firmware/%s", f.baseURL, firmware.ID),
+		Checksum:   firmware.Checksum,
+		Size:       firmware.Size,
+	}
+
+	for _, device := range devices {
+		if device.Status != entity.OTAStatusPending {
+			continue
+		}
+
+		now := time.Now()
+		device.Status = entity.OTAStatusNotified
+		device.NotifiedAt = &now
+		// broker tidak bisa dihubungi dihitung sebagai kegagalan agar rollout tidak menggantung
+		if err := f.otaPublisher.PublishOTA(device.DeviceID, notification); err != nil {
+			device.Status = entity.OTAStatusFailed
+			device.Error = fmt.Sprintf("failed to notify device: %v", err)
+		}
+		if _, err := f.firmwareRepo.UpdateRolloutDevice(ctx, device, []string{entity.OTAStatusPending}); err != nil {
+			log.Printf("Failed to update rollout device %s: %v", device.DeviceID, err)
+		}
+	}
+}
+
+func (f *firmwareUsecase) resolveTargets(ctx context.Context, requester *entity.Requester, firmware *entity.Firmware, req *entity.RolloutRequest) ([]uuid.UUID, error) {
+	if len(req.DeviceIDs) == 0 && len(req.GroupIDs) == 0 {
+		return nil, fmt.Errorf("%w: device_ids atau group_ids wajib diisi", entity.ErrInvalidRequest)
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var targets []uuid.UUID
+	for _, deviceID := range req.DeviceIDs {
+		device, err := f.deviceRepo.GetByID(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+		if !requester.CanAccessDevice(device) || device.UserID != firmware.UserID {
+			return nil, entity.ErrDeviceNotFound
+		}
+		if device.Type != firmware.DeviceType {
+			return nil, fmt.Errorf("%w: perangkat %s bertipe %s, firmware untuk %s", entity.ErrInvalidRequest, device.ID, device.Type, firmware.DeviceType)
+		}
+		if !seen[device.ID] {
+			seen[device.ID] = true
+			targets = append(targets, device.ID)
+		}
+	}
+
+	// group boleh berisi campuran tipe, perangkat dengan tipe lain dilewati
+	for _, groupID := range req.GroupIDs {
+		group, err := f.groupRepo.GetByID(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+		if !requester.CanAccess(group.UserID) || group.UserID != firmware.UserID {
+			return nil, entity.ErrGroupNotFound
+		}
+		for _, device := range group.Devices {
+			if device.Type != firmware.DeviceType || !requester.CanAccessDevice(device) || seen[device.ID] {
+				continue
+			}
+			seen[device.ID] = true
+			targets = append(targets, device.ID)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: tidak ada perangkat %s pada target", entity.ErrInvalidRequest, firmware.DeviceType)
+	}
+	return targets, nil
+}
+
+// assignStages mengacak urutan perangkat secara deterministik per rollout lalu membaginya
+// ke stage sesuai persentase kumulatif, stage pertama selalu berisi minimal satu perangkat
+func assignStages(rolloutID uuid.UUID, targets []uuid.UUID, stages []int) []*entity.RolloutDevice {
+	order := func(id uuid.UUID) []byte {
+		sum := sha256.Sum256(append(rolloutID[:], id[:]...))
+		return sum[:]
+	}
+	sorted := append([]uuid.UUID(nil), targets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(order(sorted[i]), order(sorted[j])) < 0
+	})
+
+	devices := make([]*entity.RolloutDevice, len(sorted))
+	stage := 0
+	for i, deviceID := range sorted {
+		for i >= int(math.Ceil(float64(len(sorted)*stages[stage])/100)) {
+			stage++
+		}
+		devices[i] = &entity.RolloutDevice{
+			DeviceID: deviceID,
+			Stage:    stage,
+			Status:   entity.OTAStatusPending,
+		}
+	}
+	return devices
+}
+
+func (f *firmwareUsecase) recordFirmwareVersion(ctx context.Context, deviceID uuid.UUID, firmware *entity.Firmware) {
+	device, err := f.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		log.Printf("Failed to load device %s for firmware version: %v", deviceID, err)
+		return
+	}
+	device.FirmwareVersion = firmware.Version
+	if err := f.deviceRepo.Update(ctx, device); err != nil {
+		log.Printf("Failed to record firmware version of %s: %v", deviceID, err)
+	}
+}
+
+func (f *firmwareUsecase) clearNotification(deviceID uuid.UUID) {
+	if err := f.otaPublisher.PublishOTA(deviceID, nil); err != nil {
+		log.Printf("Failed to clear OTA notification of %s: %v", deviceID, err)
+	}
+}
+
+func (f *firmwareUsecase) publishRolloutEvent(ctx context.Context, eventType string, rollout *entity.FirmwareRollout, stats *entity.RolloutStats) {
+	rollout.Stats = stats
+	f.publisher.Publish(ctx, &entity.Event{
+		Type:   eventType,
+		UserID: rollout.UserID,
+		Data:   rollout,
+	})
+}
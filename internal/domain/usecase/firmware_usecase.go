@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type firmwareUsecase struct {
+	firmwareRepo iface.FirmwareRepository
+	deviceRepo   iface.DeviceRepository
+	groupRepo    iface.GroupRepository
+	store        iface.ArtifactStore
+	otaPublisher iface.OTAPublisher
+	publisher    iface.EventPublisher
+	baseURL      string
+}
+
+func NewFirmwareUsecase(firmwareRepo iface.FirmwareRepository, deviceRepo iface.DeviceRepository, groupRepo iface.GroupRepository, store iface.ArtifactStore, otaPublisher iface.OTAPublisher, publisher iface.EventPublisher, baseURL string) iface.FirmwareUseCase {
+	return &firmwareUsecase{
+		firmwareRepo: firmwareRepo,
+		deviceRepo:   deviceRepo,
+		groupRepo:    groupRepo,
+		store:        store,
+		otaPublisher: otaPublisher,
+		publisher:    publisher,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (f *firmwareUsecase) Upload(ctx context.Context, requester *entity.Requester, req *entity.FirmwareRequest, artifact io.Reader) (*entity.Firmware, error) {
+	existing, err := f.firmwareRepo.List(ctx, &requester.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fw := range existing {
+		if fw.Version == req.Version && fw.DeviceType == req.DeviceType {
+			return nil, fmt.Errorf("%w: %s untuk %s", entity.ErrFirmwareExists, req.Version, req.DeviceType)
+		}
+	}
+
+	firmware := &entity.Firmware{
+		ID:         uuid.New(),
+		UserID:     requester.UserID,
+		Version:    req.Version,
+		DeviceType: req.DeviceType,
+		Notes:      req.Notes,
+	}
+	firmware.StorageKey = firmware.ID.String() + ".bin"
+
+	size, checksum, err := f.store.Save(ctx, firmware.StorageKey, artifact)
+	if err != nil {
+		return nil, err
+	}
+	if req.Checksum != "" && !strings.EqualFold(req.Checksum, checksum) {
+		f.deleteArtifact(ctx, firmware.StorageKey)
+		return nil, fmt.Errorf("%w: checksum tidak cocok, server menghitung %s", entity.ErrInvalidRequest, checksum)
+	}
+	firmware.Size = size
+	firmware.Checksum = checksum
+
+	if err := f.firmwareRepo.Create(ctx, firmware); err != nil {
+		f.deleteArtifact(ctx, firmware.StorageKey)
+		return nil, err
+	}
+	return firmware, nil
+}
+
+func (f *firmwareUsecase) Get(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.Firmware, error) {
+	firmware, err := f.firmwareRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccess(firmware.UserID) {
+		return nil, entity.ErrFirmwareNotFound
+	}
+	return firmware, nil
+}
+
+func (f *firmwareUsecase) List(ctx context.Context, requester *entity.Requester) ([]*entity.Firmware, error) {
+	if requester.IsAdmin() {
+		return f.firmwareRepo.List(ctx, nil)
+	}
+	return f.firmwareRepo.List(ctx, &requester.UserID)
+}
+
+// Delete hanya untuk firmware yang belum pernah dipakai rollout, agar riwayat rollout tetap utuh
+func (f *firmwareUsecase) Delete(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	firmware, err := f.Get(ctx, requester, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := f.firmwareRepo.CountRollouts(ctx, firmware.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: firmware sudah dipakai %d rollout", entity.ErrInvalidRequest, count)
+	}
+
+	if err := f.firmwareRepo.Delete(ctx, firmware.ID); err != nil {
+		return err
+	}
+	f.deleteArtifact(ctx, firmware.StorageKey)
+	return nil
+}
+
+func (f *firmwareUsecase) OpenArtifact(ctx context.Context, device *entity.Device, id uuid.UUID) (*entity.Firmware, io.ReadCloser, error) {
+	firmware, err := f.firmwareRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if firmware.UserID != device.UserID || firmware.DeviceType != device.Type {
+		return nil, nil, entity.ErrFirmwareNotFound
+	}
+
+	artifact, err := f.store.Open(ctx, firmware.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open firmware artifact: %w", err)
+	}
+	return firmware, artifact, nil
+}
+
+func (f *firmwareUsecase) deleteArtifact(ctx context.Context, key string) {
+	if err := f.store.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete firmware artifact %s: %v", key, err)
+	}
+}
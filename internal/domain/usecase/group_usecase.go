@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type groupUsecase struct {
+	groupRepo      iface.GroupRepository
+	deviceRepo     iface.DeviceRepository
+	monitoringRepo iface.MonitoringRepository
+	metricRepo     iface.MetricRepository
+	cache0         *db.Client
+}
+
+func NewGroupUsecase(groupRepo iface.GroupRepository, deviceRepo iface.DeviceRepository, monitoringRepo iface.MonitoringRepository, metricRepo iface.MetricRepository, cache *db.Client) iface.GroupUseCase {
+	return &groupUsecase{
+		groupRepo:      groupRepo,
+		deviceRepo:     deviceRepo,
+		monitoringRepo: monitoringRepo,
+		metricRepo:     metricRepo,
+		cache0:         cache,
+	}
+}
+
+func (g *groupUsecase) Create(ctx context.Context, requester *entity.Requester, req *entity.DeviceGroupRequest) (*entity.DeviceGroup, error) {
+	if err := g.ensureUniqueName(ctx, requester.UserID, uuid.Nil, req.Name); err != nil {
+		return nil, err
+	}
+
+	group := &entity.DeviceGroup{
+		UserID:      requester.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := g.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (g *groupUsecase) Get(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.DeviceGroup, error) {
+	group, err := g.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccess(group.UserID) {
+		return nil, entity.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+func (g *groupUsecase) List(ctx context.Context, requester *entity.Requester) ([]*entity.DeviceGroup, error) {
+	if requester.IsAdmin() {
+		return g.groupRepo.List(ctx, nil)
+	}
+	return g.groupRepo.List(ctx, &requester.UserID)
+}
+
+func (g *groupUsecase) Update(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.DeviceGroupRequest) (*entity.DeviceGroup, error) {
+	group, err := g.Get(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.ensureUniqueName(ctx, group.UserID, group.ID, req.Name); err != nil {
+		return nil, err
+	}
+
+	group.Name = req.Name
+	group.Description = req.Description
+	if err := g.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (g *groupUsecase) Delete(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	group, err := g.Get(ctx, requester, id)
+	if err != nil {
+		return err
+	}
+	if err := g.groupRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	invalidateDeviceMeta(ctx, g.cache0, deviceIDs(group.Devices)...)
+	return nil
+}
+
+// AddDevices menambahkan perangkat ke group, perangkat harus milik pemilik group yang sama
+func (g *groupUsecase) AddDevices(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.GroupMembersRequest) (*entity.DeviceGroup, error) {
+	group, err := g.Get(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*entity.Device, 0, len(req.DeviceIDs))
+	for _, deviceID := range req.DeviceIDs {
+		device, err := g.deviceRepo.GetByID(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+		if !requester.CanAccessDevice(device) || device.UserID != group.UserID {
+			return nil, entity.ErrDeviceNotFound
+		}
+		devices = append(devices, device)
+	}
+
+	if err := g.groupRepo.AddDevices(ctx, group.ID, devices); err != nil {
+		return nil, err
+	}
+	invalidateDeviceMeta(ctx, g.cache0, req.DeviceIDs...)
+	return g.groupRepo.GetByID(ctx, group.ID)
+}
+
+func (g *groupUsecase) RemoveDevice(ctx context.Context, requester *entity.Requester, id, deviceID uuid.UUID) error {
+	group, err := g.Get(ctx, requester, id)
+	if err != nil {
+		return err
+	}
+	if err := g.groupRepo.RemoveDevice(ctx, group.ID, deviceID); err != nil {
+		return err
+	}
+	invalidateDeviceMeta(ctx, g.cache0, deviceID)
+	return nil
+}
+
+// GetSeries membaca titik yang ditandai tag group_<id> saat ditulis, sehingga hasilnya mengikuti
+// keanggotaan group pada saat data dikirim, bukan keanggotaan saat ini
+func (g *groupUsecase) GetSeries(ctx context.Context, requester *entity.Requester, id uuid.UUID, startTime, endTime time.Time, every time.Duration, fn, mode string, metrics []string) (*entity.GroupSeries, error) {
+	if mode == "" {
+		mode = entity.GroupSeriesFleet
+	}
+	if mode != entity.GroupSeriesFleet && mode != entity.GroupSeriesDevice {
+		return nil, fmt.Errorf("%w: mode harus fleet atau device", entity.ErrInvalidQuery)
+	}
+	every, fn, err := resolveSeriesWindow(startTime, endTime, every, fn)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err = resolveMetrics(ctx, g.cache0, g.metricRepo, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := g.Get(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+	// API key yang dibatasi ke perangkat tertentu tidak boleh melihat agregat perangkat lain
+	if len(requester.DeviceIDs) > 0 {
+		return nil, entity.ErrForbidden
+	}
+
+	points, err := g.monitoringRepo.GetGroupSeries(ctx, group.ID, startTime, endTime, every, fn, metrics, mode == entity.GroupSeriesDevice)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &entity.GroupSeries{
+		GroupID: group.ID,
+		Start:   startTime,
+		End:     endTime,
+		Every:   every.String(),
+		Fn:      fn,
+		Mode:    mode,
+		Metrics: metrics,
+	}
+	if mode == entity.GroupSeriesFleet {
+		series.Points = points
+		return series, nil
+	}
+
+	byDevice := make(map[uuid.UUID]*entity.MonitoringSeries)
+	series.Devices = []*entity.MonitoringSeries{}
+	for _, point := range points {
+		deviceSeries, ok := byDevice[point.DeviceID]
+		if !ok {
+			deviceSeries = &entity.MonitoringSeries{
+				DeviceID: point.DeviceID,
+				Start:    startTime,
+				End:      endTime,
+				Every:    series.Every,
+				Fn:       fn,
+				Points:   []*entity.MonitoringData{},
+			}
+			byDevice[point.DeviceID] = deviceSeries
+			series.Devices = append(series.Devices, deviceSeries)
+		}
+		deviceSeries.Points = append(deviceSeries.Points, point)
+	}
+	return series, nil
+}
+
+func (g *groupUsecase) ensureUniqueName(ctx context.Context, userID, groupID uuid.UUID, name string) error {
+	groups, err := g.groupRepo.List(ctx, &userID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range groups {
+		if existing.Name == name && existing.ID != groupID {
+			return fmt.Errorf("%w: %q", entity.ErrGroupExists, name)
+		}
+	}
+	return nil
+}
+
+func deviceIDs(devices []*entity.Device) []uuid.UUID {
+	ids := make([]uuid.UUID, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+	return ids
+}
@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// metricCatalogTTL membatasi umur cache katalog, perubahan lewat API juga langsung menghapus cache
+	metricCatalogTTL = 5 * time.Minute
+	// maxQueryMetrics membatasi jumlah metric dalam satu query series/stats
+	maxQueryMetrics = 20
+)
+
+type metricUsecase struct {
+	metricRepo iface.MetricRepository
+	cache0     *db.Client
+}
+
+func NewMetricUsecase(metricRepo iface.MetricRepository, cache *db.Client) iface.MetricUseCase {
+	return &metricUsecase{
+		metricRepo: metricRepo,
+		cache0:     cache,
+	}
+}
+
+func (m *metricUsecase) List(ctx context.Context, deviceType string) ([]*entity.MetricDefinition, error) {
+	defs, err := m.metricRepo.List(ctx, deviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entity.MetricDefinition, 0, len(entity.DefaultMetrics)+len(defs))
+	for _, def := range entity.DefaultMetrics {
+		builtin := def
+		builtin.DeviceType = deviceType
+		result = append(result, &builtin)
+	}
+	return append(result, defs...), nil
+}
+
+func (m *metricUsecase) Create(ctx context.Context, req *entity.MetricDefinitionRequest) (*entity.MetricDefinition, error) {
+	if !entity.ValidMetricName(req.Name) {
+		return nil, fmt.Errorf("%w: nama metric harus huruf kecil, angka atau underscore dan diawali huruf", entity.ErrInvalidRequest)
+	}
+	if entity.IsDefaultMetric(req.Name) {
+		return nil, fmt.Errorf("%w: %s adalah metric bawaan", entity.ErrMetricExists, req.Name)
+	}
+
+	def := &entity.MetricDefinition{
+		DeviceType:  req.DeviceType,
+		Name:        req.Name,
+		Type:        req.Type,
+		Unit:        req.Unit,
+		Min:         req.Min,
+		Max:         req.Max,
+		Description: req.Description,
+	}
+	if def.Type == "" {
+		def.Type = entity.MetricTypeNumber
+	}
+	if err := validateMetricRange(def); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.metricRepo.ListByName(ctx, def.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.DeviceType == def.DeviceType {
+			return nil, fmt.Errorf("%w: %s untuk %s", entity.ErrMetricExists, def.Name, def.DeviceType)
+		}
+		// InfluxDB menolak tipe field yang berbeda untuk nama yang sama dalam satu measurement
+		if other.Type != def.Type {
+			return nil, fmt.Errorf("%w: metric %s sudah terdaftar bertipe %s untuk %s", entity.ErrInvalidRequest, def.Name, other.Type, other.DeviceType)
+		}
+	}
+
+	if err := m.metricRepo.Create(ctx, def); err != nil {
+		return nil, err
+	}
+	invalidateMetricCatalog(ctx, m.cache0, def.DeviceType)
+	return def, nil
+}
+
+func (m *metricUsecase) Update(ctx context.Context, id uuid.UUID, req *entity.MetricDefinitionUpdateRequest) (*entity.MetricDefinition, error) {
+	def, err := m.metricRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	def.Unit = req.Unit
+	def.Min = req.Min
+	def.Max = req.Max
+	def.Description = req.Description
+	if err := validateMetricRange(def); err != nil {
+		return nil, err
+	}
+
+	if err := m.metricRepo.Update(ctx, def); err != nil {
+		return nil, err
+	}
+	invalidateMetricCatalog(ctx, m.cache0, def.DeviceType)
+	return def, nil
+}
+
+// Delete hanya menghapus definisi katalog, data historis di InfluxDB tetap ada
+// tetapi telemetry baru dengan metric ini akan ditolak
+func (m *metricUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	def, err := m.metricRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.metricRepo.Delete(ctx, def.ID); err != nil {
+		return err
+	}
+	invalidateMetricCatalog(ctx, m.cache0, def.DeviceType)
+	return nil
+}
+
+func validateMetricRange(def *entity.MetricDefinition) error {
+	if def.Type != entity.MetricTypeNumber && (def.Min != nil || def.Max != nil) {
+		return fmt.Errorf("%w: min/max hanya berlaku untuk metric bertipe number", entity.ErrInvalidRequest)
+	}
+	if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+		return fmt.Errorf("%w: min tidak boleh lebih besar dari max", entity.ErrInvalidRequest)
+	}
+	return nil
+}
+
+func metricCatalogKey(deviceType string) string {
+	if deviceType == "" {
+		return "metric_catalog:all"
+	}
+	return "metric_catalog:" + deviceType
+}
+
+// loadMetricCatalog mengambil katalog metric per nama (bawaan + terdaftar) dari cache Redis,
+// fallback ke database. deviceType kosong berarti gabungan semua tipe perangkat.
+func loadMetricCatalog(ctx context.Context, cache *db.Client, metricRepo iface.MetricRepository, deviceType string) (map[string]*entity.MetricDefinition, error) {
+	var defs []*entity.MetricDefinition
+	cached, err := cache.Get(ctx, metricCatalogKey(deviceType))
+	if err != nil || json.Unmarshal([]byte(cached), &defs) != nil {
+		defs, err = metricRepo.List(ctx, deviceType)
+		if err != nil {
+			return nil, err
+		}
+		if payload, err := json.Marshal(defs); err == nil {
+			cache.Set(ctx, metricCatalogKey(deviceType), payload, metricCatalogTTL)
+		}
+	}
+
+	catalog := make(map[string]*entity.MetricDefinition, len(entity.DefaultMetrics)+len(defs))
+	for _, def := range defs {
+		catalog[def.Name] = def
+	}
+	for i := range entity.DefaultMetrics {
+		catalog[entity.DefaultMetrics[i].Name] = &entity.DefaultMetrics[i]
+	}
+	return catalog, nil
+}
+
+func invalidateMetricCatalog(ctx context.Context, cache *db.Client, deviceType string) {
+	for _, key := range []string{metricCatalogKey(deviceType), metricCatalogKey("")} {
+		if err := cache.Del(ctx, key); err != nil {
+			log.Printf("Failed to invalidate metric catalog %s: %v", key, err)
+		}
+	}
+}
+
+// normalizeFields memvalidasi metric tambahan terhadap katalog tipe perangkat dan
+// menyeragamkan nilainya sebelum ditulis ke InfluxDB. Field yang tidak valid dibuang
+// dan dicatat di log agar metric bawaan pada titik yang sama tetap tersimpan.
+func normalizeFields(ctx context.Context, cache *db.Client, metricRepo iface.MetricRepository, data *entity.MonitoringData) {
+	if len(data.Fields) > entity.MaxMetricFields {
+		dropFields(data, fmt.Sprintf("maksimal %d metric tambahan per titik", entity.MaxMetricFields))
+		return
+	}
+	if data.Meta == nil || data.Meta.Type == "" {
+		dropFields(data, "tipe perangkat tidak diketahui")
+		return
+	}
+
+	catalog, err := loadMetricCatalog(ctx, cache, metricRepo, data.Meta.Type)
+	if err != nil {
+		dropFields(data, fmt.Sprintf("katalog metric gagal dimuat: %v", err))
+		return
+	}
+	for name, value := range data.Fields {
+		if entity.IsDefaultMetric(name) {
+			dropField(data, name, "metric bawaan harus dikirim sebagai field utama")
+			continue
+		}
+		def, ok := catalog[name]
+		if !ok {
+			dropField(data, name, fmt.Sprintf("tidak terdaftar untuk tipe perangkat %s", data.Meta.Type))
+			continue
+		}
+		normalized, err := def.Normalize(value)
+		if err != nil {
+			dropField(data, name, err.Error())
+			continue
+		}
+		data.Fields[name] = normalized
+	}
+}
+
+func dropFields(data *entity.MonitoringData, reason string) {
+	log.Printf("Dropping %d extra metrics for device %s: %s", len(data.Fields), data.DeviceID, reason)
+	data.Fields = nil
+}
+
+func dropField(data *entity.MonitoringData, name, reason string) {
+	log.Printf("Dropping extra metric %s for device %s: %s", name, data.DeviceID, reason)
+	delete(data.Fields, name)
+}
+
+// resolveMetrics memvalidasi metric yang diminta query series/stats. Kosong berarti metric bawaan,
+// metric lain harus terdaftar sebagai number karena nilainya diagregasi.
+func resolveMetrics(ctx context.Context, cache *db.Client, metricRepo iface.MetricRepository, metrics []string) ([]string, error) {
+	if len(metrics) == 0 {
+		return entity.DefaultMetricNames(), nil
+	}
+	if len(metrics) > maxQueryMetrics {
+		return nil, fmt.Errorf("%w: maksimal %d metric per query", entity.ErrInvalidQuery, maxQueryMetrics)
+	}
+
+	var catalog map[string]*entity.MetricDefinition
+	seen := make(map[string]bool, len(metrics))
+	resolved := make([]string, 0, len(metrics))
+	for _, name := range metrics {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !entity.ValidMetricName(name) {
+			return nil, fmt.Errorf("%w: nama metric %q tidak valid", entity.ErrInvalidQuery, name)
+		}
+
+		if !entity.IsDefaultMetric(name) {
+			if catalog == nil {
+				var err error
+				if catalog, err = loadMetricCatalog(ctx, cache, metricRepo, ""); err != nil {
+					return nil, err
+				}
+			}
+			def, ok := catalog[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: metric %s tidak terdaftar", entity.ErrInvalidQuery, name)
+			}
+			if def.Type != entity.MetricTypeNumber {
+				return nil, fmt.Errorf("%w: metric %s bertipe %s dan tidak bisa diagregasi", entity.ErrInvalidQuery, name, def.Type)
+			}
+		}
+		resolved = append(resolved, name)
+	}
+	if len(resolved) == 0 {
+		return entity.DefaultMetricNames(), nil
+	}
+	return resolved, nil
+}
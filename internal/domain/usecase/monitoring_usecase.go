@@ -2,9 +2,12 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,29 +15,225 @@ import (
 
 type MonitoringUsecase struct {
 	monitoringRepo iface.MonitoringRepository
+	deviceRepo     iface.DeviceRepository
+	metricRepo     iface.MetricRepository
+	cache0         *db.Client
 }
 
-func NewMonitoringUsecase(repo iface.MonitoringRepository) iface.MonitoringUseCase {
+func NewMonitoringUsecase(repo iface.MonitoringRepository, deviceRepo iface.DeviceRepository, metricRepo iface.MetricRepository, cache *db.Client) iface.MonitoringUseCase {
 	return &MonitoringUsecase{
 		monitoringRepo: repo,
+		deviceRepo:     deviceRepo,
+		metricRepo:     metricRepo,
+		cache0:         cache,
 	}
-}   
+}
+
+func latestKey(deviceID uuid.UUID) string {
+	return "telemetry:latest:" + deviceID.String()
+}
+
+// streamChannel adalah channel Redis pub/sub tempat telemetry perangkat disiarkan ke semua replica API
+func streamChannel(deviceID uuid.UUID) string {
+	return "telemetry:stream:" + deviceID.String()
+}
+
+// Pastikan perangkat ada dan dimiliki requester (admin boleh semua perangkat)
+func (uc *MonitoringUsecase) authorizeDevice(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) error {
+	device, err := uc.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if !requester.CanAccessDevice(device) {
+		return entity.ErrDeviceNotFound
+	}
+	return nil
+}
 
-// Store data monitoring ke InfluxDB
+// accessibleDevices membuang perangkat yang tidak termasuk batasan perangkat requester
+func accessibleDevices(requester *entity.Requester, devices []*entity.Device) []*entity.Device {
+	filtered := make([]*entity.Device, 0, len(devices))
+	for _, device := range devices {
+		if requester.CanAccessDevice(device) {
+			filtered = append(filtered, device)
+		}
+	}
+	return filtered
+}
+
+// Store data monitoring ke InfluxDB secara asinkron (batch), dipakai jalur MQTT
 func (uc *MonitoringUsecase) StoreMonitoringData(ctx context.Context, data *entity.MonitoringData) error {
+	if err := uc.prepare(ctx, data); err != nil {
+		return err
+	}
+
+	if err := uc.monitoringRepo.Store(ctx, data); err != nil {
+		return err
+	}
+
+	uc.publish(ctx, data)
+	return nil
+}
+
+// StoreMonitoringBatch memvalidasi semua data lalu menulisnya dalam satu request blocking,
+// sehingga pemanggil (HTTP ingest) mendapat error sebenarnya jika InfluxDB gagal
+func (uc *MonitoringUsecase) StoreMonitoringBatch(ctx context.Context, data []*entity.MonitoringData) error {
+	// metadata dipakai ulang antar reading perangkat yang sama agar tidak membaca Redis per titik
+	metas := make(map[uuid.UUID]*entity.DeviceMeta)
+	for _, item := range data {
+		if item.Meta == nil {
+			item.Meta = metas[item.DeviceID]
+		}
+		if err := uc.prepare(ctx, item); err != nil {
+			return err
+		}
+		metas[item.DeviceID] = item.Meta
+	}
+
+	if err := uc.monitoringRepo.StoreBatch(ctx, data); err != nil {
+		return err
+	}
+
+	for _, item := range data {
+		uc.publish(ctx, item)
+	}
+	return nil
+}
+
+// prepare melengkapi timestamp dan metadata perangkat serta memvalidasi metric tambahan,
+// metric tambahan yang tidak valid dibuang tanpa menolak metric bawaan
+func (uc *MonitoringUsecase) prepare(ctx context.Context, data *entity.MonitoringData) error {
 	if data.DeviceID == uuid.Nil {
-		return fmt.Errorf("device_id tidak boleh kosong")
+		return fmt.Errorf("%w: device_id tidak boleh kosong", entity.ErrInvalidRequest)
 	}
 
 	if data.Timestamp.IsZero() {
 		data.Timestamp = time.Now()
 	}
+	if data.Meta == nil {
+		data.Meta = loadDeviceMeta(ctx, uc.cache0, uc.deviceRepo, data.DeviceID)
+	}
+	if len(data.Fields) > 0 {
+		normalizeFields(ctx, uc.cache0, uc.metricRepo, data)
+	}
+	return nil
+}
 
-	return uc.monitoringRepo.Store(ctx, data)
+// publish menyimpan "last known state" ke Redis dan menyiarkan data ke subscriber stream,
+// gagal cache tidak menggagalkan penyimpanan. Data susulan yang lebih lama
+// dari cache (misalnya batch dari perangkat) tidak menimpa state terakhir.
+func (uc *MonitoringUsecase) publish(ctx context.Context, data *entity.MonitoringData) {
+	if uc.isNewerThanCached(ctx, data) {
+		uc.cacheLatest(ctx, data)
+	}
+	uc.broadcast(ctx, data)
+}
+
+func (uc *MonitoringUsecase) broadcast(ctx context.Context, data *entity.MonitoringData) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if err := uc.cache0.Publish(ctx, streamChannel(data.DeviceID), payload).Err(); err != nil {
+		log.Printf("Failed to publish telemetry stream for %s: %v", data.DeviceID, err)
+	}
+}
+
+// SubscribeTelemetry berlangganan telemetry live untuk deviceIDs (kosong berarti semua perangkat milik requester).
+// Channel hasil ditutup setelah fungsi close dipanggil atau ctx selesai.
+func (uc *MonitoringUsecase) SubscribeTelemetry(ctx context.Context, requester *entity.Requester, deviceIDs []uuid.UUID) (<-chan *entity.MonitoringData, func(), error) {
+	if len(deviceIDs) == 0 {
+		devices, err := uc.deviceRepo.GetByUserID(ctx, requester.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, device := range accessibleDevices(requester, devices) {
+			deviceIDs = append(deviceIDs, device.ID)
+		}
+	} else {
+		for _, id := range deviceIDs {
+			if err := uc.authorizeDevice(ctx, requester, id); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if len(deviceIDs) == 0 {
+		return nil, nil, entity.ErrDeviceNotFound
+	}
+
+	channels := make([]string, len(deviceIDs))
+	for i, id := range deviceIDs {
+		channels[i] = streamChannel(id)
+	}
+
+	pubsub := uc.cache0.Subscribe(ctx, channels...)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, nil, fmt.Errorf("failed to subscribe telemetry stream: %w", err)
+	}
+
+	out := make(chan *entity.MonitoringData, 64)
+	go func() {
+		defer close(out)
+		for msg := range pubsub.Channel() {
+			var data entity.MonitoringData
+			if err := json.Unmarshal([]byte(msg.Payload), &data); err != nil {
+				continue
+			}
+			select {
+			case out <- &data:
+			case <-ctx.Done():
+				return
+			default:
+				// subscriber lambat, buang pesan daripada menahan pembacaan Redis
+			}
+		}
+	}()
+
+	return out, func() { pubsub.Close() }, nil
+}
+
+func (uc *MonitoringUsecase) isNewerThanCached(ctx context.Context, data *entity.MonitoringData) bool {
+	cached, err := uc.cache0.Get(ctx, latestKey(data.DeviceID))
+	if err != nil {
+		return true
+	}
+	var current entity.MonitoringData
+	if err := json.Unmarshal([]byte(cached), &current); err != nil {
+		return true
+	}
+	return !data.Timestamp.Before(current.Timestamp)
+}
+
+func (uc *MonitoringUsecase) cacheLatest(ctx context.Context, data *entity.MonitoringData) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if err := uc.cache0.Set(ctx, latestKey(data.DeviceID), payload, 0); err != nil {
+		log.Printf("Failed to cache latest telemetry for %s: %v", data.DeviceID, err)
+	}
+}
+
+// Ambil data terbaru dari cache Redis, fallback ke InfluxDB jika cache kosong
+func (uc *MonitoringUsecase) latest(ctx context.Context, deviceID uuid.UUID) (*entity.MonitoringData, error) {
+	if cached, err := uc.cache0.Get(ctx, latestKey(deviceID)); err == nil {
+		var data entity.MonitoringData
+		if err := json.Unmarshal([]byte(cached), &data); err == nil {
+			return &data, nil
+		}
+	}
+
+	data, err := uc.monitoringRepo.GetLatestByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	uc.cacheLatest(ctx, data)
+	return data, nil
 }
 
 // Ambil data monitoring berdasarkan device_id dengan filter waktu dan limit data
-func (uc *MonitoringUsecase) GetMonitoringDataByDevice(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error) {
+func (uc *MonitoringUsecase) GetMonitoringDataByDevice(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, limit int) ([]*entity.MonitoringData, error) {
 	if deviceID == uuid.Nil {
 		return nil, fmt.Errorf("device_id tidak boleh kosong")
 	}
@@ -44,29 +243,171 @@ func (uc *MonitoringUsecase) GetMonitoringDataByDevice(ctx context.Context, devi
 	if endTime.Before(startTime) {
 		return nil, fmt.Errorf("endTime harus lebih besar dari startTime")
 	}
+	if err := uc.authorizeDevice(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
 
 	return uc.monitoringRepo.GetByDeviceID(ctx, deviceID, startTime, endTime, limit)
 }
 
 // Ambil data monitoring terbaru berdasarkan device_id
-func (uc *MonitoringUsecase) GetLatestMonitoringData(ctx context.Context, deviceID uuid.UUID) (*entity.MonitoringData, error) {
+func (uc *MonitoringUsecase) GetLatestMonitoringData(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) (*entity.MonitoringData, error) {
 	if deviceID == uuid.Nil {
 		return nil, fmt.Errorf("device_id tidak boleh kosong")
 	}
+	if err := uc.authorizeDevice(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
 
-	return uc.monitoringRepo.GetLatestByDeviceID(ctx, deviceID)
+	return uc.latest(ctx, deviceID)
+}
+
+// Ambil data terbaru untuk semua perangkat milik requester
+func (uc *MonitoringUsecase) GetLatestForOwnedDevices(ctx context.Context, requester *entity.Requester) ([]*entity.DeviceResponse, error) {
+	devices, err := uc.deviceRepo.GetByUserID(ctx, requester.UserID)
+	if err != nil {
+		return nil, err
+	}
+	devices = accessibleDevices(requester, devices)
+	if len(devices) == 0 {
+		return []*entity.DeviceResponse{}, nil
+	}
+
+	keys := make([]string, len(devices))
+	for i, device := range devices {
+		keys[i] = latestKey(device.ID)
+	}
+	cached, err := uc.cache0.MGet(ctx, keys...).Result()
+	if err != nil {
+		cached = make([]interface{}, len(devices))
+	}
+
+	responses := make([]*entity.DeviceResponse, 0, len(devices))
+	for i, device := range devices {
+		resp := &entity.DeviceResponse{Device: device}
+
+		if raw, ok := cached[i].(string); ok {
+			var data entity.MonitoringData
+			if err := json.Unmarshal([]byte(raw), &data); err == nil {
+				resp.MonitoringData = &data
+			}
+		}
+		if resp.MonitoringData == nil {
+			if data, err := uc.monitoringRepo.GetLatestByDeviceID(ctx, device.ID); err == nil {
+				uc.cacheLatest(ctx, data)
+				resp.MonitoringData = data
+			}
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+const (
+	// jumlah titik yang dituju saat window dipilih otomatis
+	seriesTargetPoints = 300
+	// batas atas jumlah titik agar query tidak mengembalikan data mentah berukuran besar
+	seriesMaxPoints = 5000
+)
+
+var seriesFns = map[string]bool{"mean": true, "max": true, "min": true, "last": true}
+
+// window "rapi" yang dipakai untuk pemilihan otomatis, dari terkecil ke terbesar
+var seriesWindows = []time.Duration{
+	10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// autoWindow memilih window terkecil yang menghasilkan paling banyak seriesTargetPoints titik
+func autoWindow(span time.Duration) time.Duration {
+	for _, w := range seriesWindows {
+		if span/w <= seriesTargetPoints {
+			return w
+		}
+	}
+	return seriesWindows[len(seriesWindows)-1]
+}
+
+// resolveSeriesWindow memvalidasi rentang waktu dan fn, serta memilih window otomatis jika every = 0
+func resolveSeriesWindow(startTime, endTime time.Time, every time.Duration, fn string) (time.Duration, string, error) {
+	if !endTime.After(startTime) {
+		return 0, "", fmt.Errorf("%w: end harus lebih besar dari start", entity.ErrInvalidQuery)
+	}
+	if fn == "" {
+		fn = "mean"
+	}
+	if !seriesFns[fn] {
+		return 0, "", fmt.Errorf("%w: fn harus salah satu dari mean, max, min, last", entity.ErrInvalidQuery)
+	}
+
+	span := endTime.Sub(startTime)
+	if every <= 0 {
+		every = autoWindow(span)
+	}
+	if every < time.Second {
+		return 0, "", fmt.Errorf("%w: every minimal 1s", entity.ErrInvalidQuery)
+	}
+	if span/every > seriesMaxPoints {
+		return 0, "", fmt.Errorf("%w: every terlalu kecil untuk rentang waktu ini (maksimal %d titik)", entity.ErrInvalidQuery, seriesMaxPoints)
+	}
+	return every, fn, nil
+}
+
+// Ambil time-series yang sudah di-downsample, every = 0 berarti window dipilih otomatis
+func (uc *MonitoringUsecase) GetMonitoringSeries(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, every time.Duration, fn string, metrics []string) (*entity.MonitoringSeries, error) {
+	if deviceID == uuid.Nil {
+		return nil, fmt.Errorf("device_id tidak boleh kosong")
+	}
+	every, fn, err := resolveSeriesWindow(startTime, endTime, every, fn)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err = resolveMetrics(ctx, uc.cache0, uc.metricRepo, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.authorizeDevice(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
+
+	points, err := uc.monitoringRepo.GetSeries(ctx, deviceID, startTime, endTime, every, fn, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.MonitoringSeries{
+		DeviceID: deviceID,
+		Start:    startTime,
+		End:      endTime,
+		Every:    every.String(),
+		Fn:       fn,
+		Metrics:  metrics,
+		Points:   points,
+	}, nil
 }
 
 // Ambil statistik monitoring perangkat
-func (uc *MonitoringUsecase) GetMonitoringStats(ctx context.Context, deviceID uuid.UUID, startTime, endTime time.Time) (*entity.MonitoringStats, error) {
+func (uc *MonitoringUsecase) GetMonitoringStats(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, startTime, endTime time.Time, metrics []string) (*entity.MonitoringStats, error) {
 	if deviceID == uuid.Nil {
 		return nil, fmt.Errorf("device_id tidak boleh kosong")
 	}
 	if endTime.Before(startTime) {
 		return nil, fmt.Errorf("endTime harus lebih besar dari startTime")
 	}
+	metrics, err := resolveMetrics(ctx, uc.cache0, uc.metricRepo, metrics)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeDevice(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
 
-	return uc.monitoringRepo.GetStats(ctx, deviceID, startTime, endTime)
+	return uc.monitoringRepo.GetStats(ctx, deviceID, startTime, endTime, metrics)
 }
 
 // Hapus data lama berdasarkan periode retensi
@@ -77,3 +418,25 @@ func (uc *MonitoringUsecase) DeleteOldMonitoringData(ctx context.Context, retent
 
 	return uc.monitoringRepo.DeleteOldData(ctx, retentionPeriod)
 }
+
+// StartSpoolReplayer mengirim ulang telemetry yang tertahan di spool disk setiap interval
+// sampai ctx selesai. Replay dilewati selama InfluxDB belum merespon ping.
+func (uc *MonitoringUsecase) StartSpoolReplayer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.monitoringRepo.ReplaySpool(ctx); err != nil {
+				log.Printf("Failed to replay telemetry spool: %v", err)
+			}
+		}
+	}
+}
+
+func (uc *MonitoringUsecase) GetSpoolStats(ctx context.Context) *entity.SpoolStats {
+	return uc.monitoringRepo.SpoolStats()
+}
@@ -0,0 +1,344 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"monitoring/config"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const webhookQueueSize = 1000
+
+type webhookJob struct {
+	channel *entity.NotificationChannel
+	event   *entity.Event
+	body    []byte
+	attempt int
+}
+
+type notificationUsecase struct {
+	notificationRepo iface.NotificationRepository
+	cfg              config.WebhookConfig
+	httpClient       *http.Client
+	queue            chan *webhookJob
+}
+
+func NewNotificationUsecase(repo iface.NotificationRepository, cfg config.WebhookConfig) iface.NotificationUseCase {
+	return &notificationUsecase{
+		notificationRepo: repo,
+		cfg:              cfg,
+		httpClient:       newWebhookClient(cfg.Timeout),
+		queue:            make(chan *webhookJob, webhookQueueSize),
+	}
+}
+
+func (n *notificationUsecase) CreateChannel(ctx context.Context, requester *entity.Requester, req *entity.NotificationChannelRequest) (*entity.NotificationChannel, error) {
+	channel := &entity.NotificationChannel{
+		UserID:  requester.UserID,
+		Enabled: true,
+	}
+	if err := applyChannelRequest(channel, req); err != nil {
+		return nil, err
+	}
+
+	if err := n.notificationRepo.CreateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (n *notificationUsecase) GetChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) (*entity.NotificationChannel, error) {
+	channel, err := n.notificationRepo.GetChannelByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanAccess(channel.UserID) {
+		return nil, entity.ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+func (n *notificationUsecase) ListChannels(ctx context.Context, requester *entity.Requester) ([]*entity.NotificationChannel, error) {
+	if requester.IsAdmin() {
+		return n.notificationRepo.ListChannels(ctx, nil)
+	}
+	return n.notificationRepo.ListChannels(ctx, &requester.UserID)
+}
+
+func (n *notificationUsecase) UpdateChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID, req *entity.NotificationChannelRequest) (*entity.NotificationChannel, error) {
+	channel, err := n.GetChannel(ctx, requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyChannelRequest(channel, req); err != nil {
+		return nil, err
+	}
+
+	if err := n.notificationRepo.UpdateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (n *notificationUsecase) DeleteChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	if _, err := n.GetChannel(ctx, requester, id); err != nil {
+		return err
+	}
+	return n.notificationRepo.DeleteChannel(ctx, id)
+}
+
+func (n *notificationUsecase) ListDeliveries(ctx context.Context, requester *entity.Requester, channelID uuid.UUID, limit, offset int) ([]*entity.NotificationDelivery, error) {
+	if _, err := n.GetChannel(ctx, requester, channelID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 50 // default limit
+	}
+	return n.notificationRepo.ListDeliveries(ctx, channelID, limit, offset)
+}
+
+// TestChannel mengirim event "test" ke satu channel untuk memeriksa URL dan verifikasi signature
+func (n *notificationUsecase) TestChannel(ctx context.Context, requester *entity.Requester, id uuid.UUID) error {
+	channel, err := n.GetChannel(ctx, requester, id)
+	if err != nil {
+		return err
+	}
+
+	event := newEvent(&entity.Event{
+		Type:   entity.EventTest,
+		UserID: channel.UserID,
+		Data:   map[string]interface{}{"message": "webhook test"},
+	})
+	return n.enqueueEvent(channel, event)
+}
+
+// Publish mencari channel milik event.UserID yang berlangganan event tersebut dan mengantrikan pengiriman
+func (n *notificationUsecase) Publish(ctx context.Context, event *entity.Event) {
+	event = newEvent(event)
+
+	channels, err := n.notificationRepo.ListEnabledChannels(ctx, event.UserID)
+	if err != nil {
+		log.Printf("Failed to load notification channels for event %s: %v", event.Type, err)
+		return
+	}
+
+	for _, channel := range channels {
+		if !channel.Accepts(event.Type) {
+			continue
+		}
+		if err := n.enqueueEvent(channel, event); err != nil {
+			log.Printf("Failed to enqueue webhook %s for channel %s: %v", event.Type, channel.ID, err)
+		}
+	}
+}
+
+func (n *notificationUsecase) Start(ctx context.Context) {
+	workers := n.cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-n.queue:
+					n.deliver(ctx, job)
+				}
+			}
+		}()
+	}
+	<-ctx.Done()
+}
+
+func (n *notificationUsecase) enqueueEvent(channel *entity.NotificationChannel, event *entity.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	n.enqueue(&webhookJob{channel: channel, event: event, body: body, attempt: 1})
+	return nil
+}
+
+func (n *notificationUsecase) enqueue(job *webhookJob) {
+	select {
+	case n.queue <- job:
+	default:
+		log.Printf("Webhook queue full, dropping %s for channel %s", job.event.Type, job.channel.ID)
+	}
+}
+
+// deliver mengirim satu percobaan webhook, mencatatnya di delivery log,
+// dan menjadwalkan ulang dengan exponential backoff jika gagal. Retry hanya hidup di memori
+// (time.AfterFunc), jadi percobaan yang belum jatuh tempo hilang saat server restart;
+// delivery log tetap mencatat percobaan yang sudah dilakukan.
+func (n *notificationUsecase) deliver(ctx context.Context, job *webhookJob) {
+	if job.attempt > 1 && !n.reloadChannel(ctx, job) {
+		return
+	}
+
+	start := time.Now()
+	statusCode, err := n.send(ctx, job)
+
+	delivery := &entity.NotificationDelivery{
+		ChannelID:  job.channel.ID,
+		EventID:    job.event.ID,
+		EventType:  job.event.Type,
+		Attempt:    job.attempt,
+		StatusCode: statusCode,
+		Success:    err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		delivery.Error = truncate(err.Error(), 500)
+	}
+	if err := n.notificationRepo.CreateDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record webhook delivery: %v", err)
+	}
+
+	if err == nil {
+		return
+	}
+	n.scheduleRetry(job)
+}
+
+func (n *notificationUsecase) scheduleRetry(job *webhookJob) {
+	if job.attempt >= n.cfg.MaxAttempts {
+		return
+	}
+	backoff := n.cfg.BaseBackoff * time.Duration(1<<(job.attempt-1))
+	job.attempt++
+	time.AfterFunc(backoff, func() { n.enqueue(job) })
+}
+
+// reloadChannel memuat ulang channel sebelum retry agar perubahan URL/secret berlaku dan retry
+// berhenti jika channel dihapus, dinonaktifkan atau tidak lagi berlangganan event tersebut
+func (n *notificationUsecase) reloadChannel(ctx context.Context, job *webhookJob) bool {
+	channel, err := n.notificationRepo.GetChannelByID(ctx, job.channel.ID)
+	if err != nil {
+		if errors.Is(err, entity.ErrChannelNotFound) {
+			log.Printf("Dropping webhook %s retry, channel %s was deleted", job.event.Type, job.channel.ID)
+			return false
+		}
+		log.Printf("Failed to reload notification channel %s: %v", job.channel.ID, err)
+		n.scheduleRetry(job)
+		return false
+	}
+	if !channel.Enabled || !channel.Accepts(job.event.Type) {
+		log.Printf("Dropping webhook %s retry, channel %s no longer accepts it", job.event.Type, channel.ID)
+		return false
+	}
+	job.channel = channel
+	return true
+}
+
+func (n *notificationUsecase) send(ctx context.Context, job *webhookJob) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.channel.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-monitoring-webhook")
+	req.Header.Set("X-Webhook-Event", job.event.Type)
+	req.Header.Set("X-Webhook-Id", job.event.ID.String())
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(job.attempt))
+	// signature mencakup timestamp ("<timestamp>.<body>") agar penerima bisa menolak replay
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+utils.HmacSHA256(job.channel.Secret, append([]byte(timestamp+"."), job.body...)))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookClient membuat HTTP client yang hanya mau terhubung ke alamat publik. Pemeriksaan dilakukan
+// saat dial (setelah DNS resolve) dan berlaku juga untuk redirect; proxy dimatikan agar tidak melewatinya.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   utils.PublicDialControl,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// validateWebhookURL menolak skema selain http/https dan host yang jelas menunjuk ke jaringan internal.
+// Hostname lain diperiksa ulang saat dial oleh newWebhookClient.
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: url tidak valid", entity.ErrInvalidRequest)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: url harus http atau https", entity.ErrInvalidRequest)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url tidak boleh menuju jaringan internal", entity.ErrInvalidRequest)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !utils.IsPublicIP(ip) {
+		return fmt.Errorf("%w: url tidak boleh menuju jaringan internal", entity.ErrInvalidRequest)
+	}
+	return nil
+}
+
+func applyChannelRequest(channel *entity.NotificationChannel, req *entity.NotificationChannelRequest) error {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return err
+	}
+	channel.Name = req.Name
+	channel.URL = req.URL
+	channel.Secret = req.Secret
+	channel.Events = req.Events
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// newEvent melengkapi ID dan waktu kejadian jika belum diisi
+func newEvent(event *entity.Event) *entity.Event {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	return event
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
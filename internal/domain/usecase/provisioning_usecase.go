@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
+	"monitoring/pkg/utils"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	provisionTTL = 15 * time.Minute
+	// claimCodeAlphabet tanpa karakter yang mudah tertukar (0/O, 1/I/L)
+	claimCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	claimCodeLength   = 8
+	// maxPendingPerSource membatasi provisioning yang belum diklaim per MAC dan per IP dalam satu provisionTTL
+	maxPendingPerSource = 5
+)
+
+// Layout Redis:
+//
+//	provision:<provisionID>    JSON entity.PendingProvision, TTL = provisionTTL
+//	provision_code:<claimCode> provisionID, dihapus saat diklaim
+//	provision_claimed:<id>     channel pub/sub untuk membangunkan WaitForClaim
+//	provision_limit:<src>      jumlah request per mac:<mac> / ip:<ip>, TTL = provisionTTL
+func provisionKey(provisionID string) string {
+	return "provision:" + provisionID
+}
+
+func provisionCodeKey(code string) string {
+	return "provision_code:" + code
+}
+
+func provisionLimitKey(source string) string {
+	return "provision_limit:" + source
+}
+
+func provisionClaimedChannel(provisionID string) string {
+	return "provision_claimed:" + provisionID
+}
+
+type provisioningUsecase struct {
+	deviceRepo    iface.DeviceRepository
+	deviceUsecase iface.DeviceUseCase
+	cache0        *db.Client
+}
+
+func NewProvisioningUsecase(deviceRepo iface.DeviceRepository, deviceUsecase iface.DeviceUseCase, cache *db.Client) iface.ProvisioningUseCase {
+	return &provisioningUsecase{
+		deviceRepo:    deviceRepo,
+		deviceUsecase: deviceUsecase,
+		cache0:        cache,
+	}
+}
+
+func (p *provisioningUsecase) Request(ctx context.Context, req *entity.ProvisionRequest) (*entity.ProvisionTicket, error) {
+	mac, err := net.ParseMAC(req.MacAddress)
+	if err != nil {
+		return nil, fmt.Errorf("%w: mac_address tidak valid", entity.ErrInvalidRequest)
+	}
+	if _, err := p.deviceRepo.GetByMacAddress(ctx, mac.String()); err == nil {
+		return nil, entity.ErrDeviceExists
+	}
+	if req.PublicKey != "" && !utils.ValidPublicKey(req.PublicKey) {
+		return nil, fmt.Errorf("%w: public_key harus X25519 (32 byte, base64)", entity.ErrInvalidRequest)
+	}
+
+	provisionID := strings.ToLower(req.ProvisionID)
+	if provisionID == "" {
+		if provisionID, err = randomHex(16); err != nil {
+			return nil, err
+		}
+	}
+
+	// Perangkat yang mengulang request (misalnya reboot) mendapat claim code yang sama. provision_id
+	// terlihat oleh klien broker lain, jadi ticket hanya dikembalikan jika MAC dan public key sama persis.
+	if pending, err := p.getPending(ctx, provisionID); err == nil {
+		if pending.MacAddress != mac.String() || pending.PublicKey != req.PublicKey {
+			return nil, entity.ErrProvisionConflict
+		}
+		if pending.Status != entity.ProvisionStatusPending {
+			return nil, entity.ErrDeviceExists
+		}
+		return &entity.ProvisionTicket{ProvisionID: pending.ProvisionID, ClaimCode: pending.ClaimCode, ExpiresAt: pending.ExpiresAt}, nil
+	}
+
+	if err := p.limitSources(ctx, "mac:"+mac.String(), "ip:"+req.SourceIP); err != nil {
+		return nil, err
+	}
+
+	code, err := p.reserveClaimCode(ctx, provisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &entity.PendingProvision{
+		ProvisionID: provisionID,
+		ClaimCode:   code,
+		MacAddress:  mac.String(),
+		Type:        req.Type,
+		Hostname:    req.Hostname,
+		IPAddress:   req.IPAddress,
+		PublicKey:   req.PublicKey,
+		Status:      entity.ProvisionStatusPending,
+		ExpiresAt:   time.Now().Add(provisionTTL),
+	}
+	if err := p.savePending(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	return &entity.ProvisionTicket{ProvisionID: provisionID, ClaimCode: code, ExpiresAt: pending.ExpiresAt}, nil
+}
+
+func (p *provisioningUsecase) Claim(ctx context.Context, requester *entity.Requester, req *entity.ClaimRequest) (*entity.Device, error) {
+	code := normalizeClaimCode(req.ClaimCode)
+	provisionID, err := p.cache0.Get(ctx, provisionCodeKey(code))
+	if err != nil {
+		return nil, entity.ErrProvisionNotFound
+	}
+	// Hapus claim code secara atomik agar dua user tidak bisa mengklaim perangkat yang sama
+	if n, err := p.cache0.Client.Del(ctx, provisionCodeKey(code)).Result(); err != nil || n == 0 {
+		return nil, entity.ErrProvisionNotFound
+	}
+
+	pending, err := p.getPending(ctx, provisionID)
+	if err != nil || pending.Status != entity.ProvisionStatusPending {
+		return nil, entity.ErrProvisionNotFound
+	}
+
+	name := req.Name
+	if name == "" {
+		name = pending.Hostname
+	}
+	device := &entity.Device{
+		Name:       name,
+		Type:       pending.Type,
+		MacAddress: pending.MacAddress,
+		IPAddress:  pending.IPAddress,
+		Location:   req.Location,
+		UserID:     requester.UserID,
+	}
+	if _, err := p.deviceRepo.GetByMacAddress(ctx, device.MacAddress); err == nil {
+		return nil, entity.ErrDeviceExists
+	}
+
+	token, err := p.deviceUsecase.Create(ctx, device)
+	if err != nil {
+		// kembalikan claim code agar user bisa mencoba lagi
+		p.cache0.Set(ctx, provisionCodeKey(code), provisionID, time.Until(pending.ExpiresAt))
+		return nil, err
+	}
+
+	pending.Status = entity.ProvisionStatusClaimed
+	pending.DeviceID = &device.ID
+	pending.Token = token
+	pending.ExpiresAt = time.Now().Add(provisionTTL)
+	if err := p.savePending(ctx, pending); err != nil {
+		return nil, err
+	}
+	p.cache0.Publish(ctx, provisionClaimedChannel(provisionID), device.ID.String())
+
+	return device, nil
+}
+
+func (p *provisioningUsecase) Poll(ctx context.Context, provisionID string) (*entity.ProvisionStatus, error) {
+	pending, err := p.getPending(ctx, strings.ToLower(provisionID))
+	if err != nil {
+		return nil, entity.ErrProvisionNotFound
+	}
+	if pending.Status == entity.ProvisionStatusPending {
+		return &entity.ProvisionStatus{Status: pending.Status}, nil
+	}
+
+	// Kredensial hanya diberikan sekali, penghapusan atomik mencegah dua pengambil
+	if n, err := p.cache0.Client.Del(ctx, provisionKey(pending.ProvisionID)).Result(); err != nil || n == 0 {
+		return nil, entity.ErrProvisionNotFound
+	}
+
+	device, err := p.deviceRepo.GetByID(ctx, *pending.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	device.User = nil
+
+	credential := &entity.DeviceCredential{Device: device, Token: pending.Token}
+	if pending.PublicKey == "" {
+		return &entity.ProvisionStatus{Status: pending.Status, Credential: credential}, nil
+	}
+	payload, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := utils.SealToPublicKey(pending.PublicKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.ProvisionStatus{Status: pending.Status, SealedCredential: sealed}, nil
+}
+
+func (p *provisioningUsecase) WaitForClaim(ctx context.Context, provisionID string) (*entity.ProvisionStatus, error) {
+	pending, err := p.getPending(ctx, provisionID)
+	if err != nil {
+		return nil, entity.ErrProvisionNotFound
+	}
+
+	// Subscribe sebelum cek status agar klaim yang terjadi di antaranya tidak terlewat
+	pubsub := p.cache0.Subscribe(ctx, provisionClaimedChannel(provisionID))
+	defer pubsub.Close()
+
+	expired := time.NewTimer(time.Until(pending.ExpiresAt))
+	defer expired.Stop()
+
+	for {
+		status, err := p.Poll(ctx, provisionID)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status != entity.ProvisionStatusPending {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-expired.C:
+			return nil, entity.ErrProvisionNotFound
+		case <-pubsub.Channel():
+		}
+	}
+}
+
+// limitSources menolak request baru jika salah satu sumber (MAC/IP) sudah mencapai maxPendingPerSource.
+// Sumber kosong (misalnya "ip:" untuk request lewat MQTT) dilewati.
+func (p *provisioningUsecase) limitSources(ctx context.Context, sources ...string) error {
+	for _, source := range sources {
+		if strings.HasSuffix(source, ":") {
+			continue
+		}
+		count, err := p.cache0.Incr(ctx, provisionLimitKey(source)).Result()
+		if err != nil {
+			return err
+		}
+		if count == 1 {
+			p.cache0.Expire(ctx, provisionLimitKey(source), provisionTTL)
+		}
+		if count > maxPendingPerSource {
+			return fmt.Errorf("%w: terlalu banyak provisioning dari %s", entity.ErrTooManyRequests, source)
+		}
+	}
+	return nil
+}
+
+// reserveClaimCode memilih claim code acak yang belum dipakai provisioning lain
+func (p *provisioningUsecase) reserveClaimCode(ctx context.Context, provisionID string) (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := randomClaimCode()
+		if err != nil {
+			return "", err
+		}
+		ok, err := p.cache0.SetNX(ctx, provisionCodeKey(code), provisionID, provisionTTL).Result()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return code, nil
+		}
+	}
+	return "", errors.New("failed to allocate claim code")
+}
+
+func (p *provisioningUsecase) getPending(ctx context.Context, provisionID string) (*entity.PendingProvision, error) {
+	raw, err := p.cache0.Get(ctx, provisionKey(provisionID))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, entity.ErrProvisionNotFound
+		}
+		return nil, err
+	}
+	var pending entity.PendingProvision
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+func (p *provisioningUsecase) savePending(ctx context.Context, pending *entity.PendingProvision) error {
+	payload, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return p.cache0.Set(ctx, provisionKey(pending.ProvisionID), payload, time.Until(pending.ExpiresAt))
+}
+
+func randomClaimCode() (string, error) {
+	code := make([]byte, claimCodeLength)
+	max := big.NewInt(int64(len(claimCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = claimCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeClaimCode menerima claim code yang diketik user dengan huruf kecil, spasi atau tanda hubung
+func normalizeClaimCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/db"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisStub adalah server RESP minimal dengan perintah yang dipakai provisioning (tanpa TTL)
+type redisStub struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func startRedisStub(t *testing.T) *db.Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stub := &redisStub{data: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return &db.Client{Client: client}
+}
+
+func (s *redisStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $<len>
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (s *redisStub) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := s.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		nx := false
+		for _, opt := range args[3:] {
+			nx = nx || strings.EqualFold(opt, "NX")
+		}
+		if _, exists := s.data[args[1]]; nx && exists {
+			return "$-1\r\n"
+		}
+		s.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "INCR":
+		count, _ := strconv.Atoi(s.data[args[1]])
+		count++
+		s.data[args[1]] = strconv.Itoa(count)
+		return fmt.Sprintf(":%d\r\n", count)
+	case "EXPIRE":
+		return ":1\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command %s\r\n", args[0])
+}
+
+// unregisteredDevices menganggap semua MAC belum terdaftar
+type unregisteredDevices struct {
+	iface.DeviceRepository
+}
+
+func (unregisteredDevices) GetByMacAddress(ctx context.Context, mac string) (*entity.Device, error) {
+	return nil, entity.ErrDeviceNotFound
+}
+
+func TestProvisionRequestRejectsReusedID(t *testing.T) {
+	uc := NewProvisioningUsecase(unregisteredDevices{}, nil, startRedisStub(t))
+	ctx := context.Background()
+
+	const (
+		provisionID = "0123456789abcdef0123456789abcdef"
+		deviceKey   = "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA="
+		attackerKey = "IB8eHRwbGhkYFxYVFBMSERAPDg0MCwoJCAcGBQQDAgE="
+	)
+	first, err := uc.Request(ctx, &entity.ProvisionRequest{
+		ProvisionID: provisionID,
+		MacAddress:  "aa:bb:cc:dd:ee:01",
+		PublicKey:   deviceKey,
+		SourceIP:    "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	// perangkat yang sama mengulang request mendapat claim code yang sama
+	retry, err := uc.Request(ctx, &entity.ProvisionRequest{
+		ProvisionID: provisionID,
+		MacAddress:  "AA:BB:CC:DD:EE:01",
+		PublicKey:   deviceKey,
+		SourceIP:    "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if retry.ClaimCode != first.ClaimCode {
+		t.Errorf("retry claim code: got %s, want %s", retry.ClaimCode, first.ClaimCode)
+	}
+
+	cases := []struct {
+		name string
+		req  *entity.ProvisionRequest
+	}{
+		{"other public key", &entity.ProvisionRequest{ProvisionID: provisionID, MacAddress: "aa:bb:cc:dd:ee:01", PublicKey: attackerKey}},
+		{"other mac", &entity.ProvisionRequest{ProvisionID: provisionID, MacAddress: "aa:bb:cc:dd:ee:02", PublicKey: deviceKey}},
+		{"without public key", &entity.ProvisionRequest{ProvisionID: provisionID, MacAddress: "aa:bb:cc:dd:ee:01"}},
+	}
+	for _, tc := range cases {
+		tc.req.SourceIP = "10.0.0.3"
+		ticket, err := uc.Request(ctx, tc.req)
+		if !errors.Is(err, entity.ErrProvisionConflict) {
+			t.Errorf("%s: got ticket %+v, err %v; want ErrProvisionConflict", tc.name, ticket, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type shadowUsecase struct {
+	shadowRepo iface.ShadowRepository
+	deviceRepo iface.DeviceRepository
+	publisher  iface.ShadowPublisher
+}
+
+func NewShadowUsecase(shadowRepo iface.ShadowRepository, deviceRepo iface.DeviceRepository, publisher iface.ShadowPublisher) iface.ShadowUseCase {
+	return &shadowUsecase{
+		shadowRepo: shadowRepo,
+		deviceRepo: deviceRepo,
+		publisher:  publisher,
+	}
+}
+
+func (s *shadowUsecase) Get(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) (*entity.DeviceShadow, error) {
+	if err := s.ensureDeviceAccess(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
+
+	shadow, err := s.shadowRepo.Get(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	shadow.Delta = shadow.ComputeDelta()
+	return shadow, nil
+}
+
+func (s *shadowUsecase) UpdateDesired(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID, req *entity.ShadowUpdateRequest) (*entity.DeviceShadow, error) {
+	if err := s.ensureDeviceAccess(ctx, requester, deviceID); err != nil {
+		return nil, err
+	}
+
+	shadow, err := s.shadowRepo.Update(ctx, deviceID, func(shadow *entity.DeviceShadow) error {
+		if req.Version != nil && *req.Version != shadow.Version {
+			return fmt.Errorf("%w: current version is %d", entity.ErrShadowConflict, shadow.Version)
+		}
+
+		desired, changed := mergeState(shadow.Desired, req.State)
+		if !changed {
+			return nil
+		}
+		now := time.Now()
+		shadow.Desired = desired
+		shadow.Version++
+		shadow.DesiredVersion++
+		shadow.DesiredUpdatedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shadow.Delta = shadow.ComputeDelta()
+	s.publishDelta(shadow)
+	return shadow, nil
+}
+
+func (s *shadowUsecase) ReportState(ctx context.Context, deviceID uuid.UUID, state map[string]interface{}) (*entity.DeviceShadow, error) {
+	shadow, err := s.shadowRepo.Update(ctx, deviceID, func(shadow *entity.DeviceShadow) error {
+		reported, changed := mergeState(shadow.Reported, state)
+		if !changed {
+			return nil
+		}
+		now := time.Now()
+		shadow.Reported = reported
+		shadow.Version++
+		shadow.ReportedVersion++
+		shadow.ReportedUpdatedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// delta selalu dipublikasikan ulang: kosong berarti perangkat sudah sesuai desired
+	shadow.Delta = shadow.ComputeDelta()
+	s.publishDelta(shadow)
+	return shadow, nil
+}
+
+func (s *shadowUsecase) SyncDelta(ctx context.Context, deviceID uuid.UUID) error {
+	shadow, err := s.shadowRepo.Get(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	shadow.Delta = shadow.ComputeDelta()
+	s.publishDelta(shadow)
+	return nil
+}
+
+func (s *shadowUsecase) publishDelta(shadow *entity.DeviceShadow) {
+	delta := &entity.ShadowDelta{
+		Version:   shadow.Version,
+		State:     shadow.Delta,
+		Timestamp: time.Now(),
+	}
+	if err := s.publisher.PublishShadowDelta(shadow.DeviceID, delta); err != nil {
+		log.Printf("Failed to publish shadow delta for %s: %v", shadow.DeviceID, err)
+	}
+}
+
+func (s *shadowUsecase) ensureDeviceAccess(ctx context.Context, requester *entity.Requester, deviceID uuid.UUID) error {
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if !requester.CanAccessDevice(device) {
+		return entity.ErrDeviceNotFound
+	}
+	return nil
+}
+
+// mergeState menerapkan JSON merge patch (RFC 7386) ke salinan state: nilai null menghapus key
+// dan object digabung secara rekursif. changed false jika hasilnya sama dengan state awal.
+func mergeState(state, patch map[string]interface{}) (map[string]interface{}, bool) {
+	merged := make(map[string]interface{}, len(state)+len(patch))
+	for key, value := range state {
+		merged[key] = value
+	}
+
+	changed := false
+	for key, value := range patch {
+		current, exists := merged[key]
+		switch v := value.(type) {
+		case nil:
+			if exists {
+				delete(merged, key)
+				changed = true
+			}
+		case map[string]interface{}:
+			currentMap, _ := current.(map[string]interface{})
+			nested, nestedChanged := mergeState(currentMap, v)
+			if nestedChanged || currentMap == nil {
+				merged[key] = nested
+				changed = true
+			}
+		default:
+			if !exists || !reflect.DeepEqual(current, value) {
+				merged[key] = value
+				changed = true
+			}
+		}
+	}
+	return merged, changed
+}
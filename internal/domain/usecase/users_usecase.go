@@ -10,18 +10,23 @@ import (
 	"monitoring/pkg/jwt"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type authUsecase struct {
-	userRepo iface.UserRepository
-	cache0   *db.Client
+	userRepo   iface.UserRepository
+	cache0     *db.Client
+	jwtService jwt.JwtService
+	publisher  iface.EventPublisher
 }
 
-func NewAuthUsecase(userRepo iface.UserRepository, cache *db.Client) iface.AuthUsecase {
+func NewAuthUsecase(userRepo iface.UserRepository, cache *db.Client, jwtService jwt.JwtService, publisher iface.EventPublisher) iface.AuthUsecase {
 	return &authUsecase{
-		userRepo: userRepo,
-		cache0:   cache,
+		userRepo:   userRepo,
+		cache0:     cache,
+		jwtService: jwtService,
+		publisher:  publisher,
 	}
 }
 
@@ -44,16 +49,18 @@ func (u *authUsecase) Register(ctx context.Context, req *entity.RegisterRequest)
 		return err
 	}
 
+	// registrasi publik selalu membuat role user, role lain hanya diberikan admin lewat PUT /users/:id/role
 	user := entity.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Password: string(hashedPassword),
+		Role:     entity.RoleUser,
 	}
 
 	return u.userRepo.Create(ctx, &user)
 }
 
-func (u *authUsecase) Login(ctx context.Context, username, password string) (string, string, error) {
+func (u *authUsecase) Login(ctx context.Context, username, password string, meta *entity.SessionMeta) (string, string, error) {
 	user, err := u.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		return "", "", errors.New("invalid username or password")
@@ -64,60 +71,106 @@ func (u *authUsecase) Login(ctx context.Context, username, password string) (str
 		return "", "", errors.New("invalid username or password")
 	}
 
-	// generate access & refresh token
-	accessToken, err := jwt.GenerateToken(user.ID.String(), time.Minute*15)
+	// setiap login membuat sesi baru sehingga sesi di perangkat lain tetap berlaku
+	now := time.Now()
+	session := &entity.Session{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		UserAgent: meta.UserAgent,
+		IPAddress: meta.IPAddress,
+		CreatedAt: now,
+	}
+
+	return u.issueTokens(ctx, user, session)
+}
+
+func (u *authUsecase) RefreshToken(ctx context.Context, refreshToken string, meta *entity.SessionMeta) (string, string, error) {
+	claims, err := u.jwtService.ValidateToken(refreshToken)
+	if err != nil || claims.TokenType != jwt.TokenTypeRefresh {
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	session, err := u.getSession(ctx, claims.SessionID)
 	if err != nil {
-		return "", "", err
+		return "", "", errors.New("refresh token not found or expired")
 	}
 
-	refreshToken, err := jwt.GenerateToken(user.ID.String(), time.Hour*24*7)
+	// Tandai jti sebagai sudah dirotasi secara atomik. Jika sudah pernah dirotasi
+	// atau bukan token terbaru family ini, token tersebut dipakai ulang (kemungkinan dicuri).
+	rotated, err := u.markRotated(ctx, claims)
 	if err != nil {
 		return "", "", err
 	}
+	if !rotated || session.RefreshJTI != claims.ID {
+		u.handleTokenReuse(ctx, session, claims, meta)
+		return "", "", errors.New("refresh token reuse detected, session revoked")
+	}
 
-	key := "refresh_token:" + user.ID.String()
-	err = u.cache0.Set(ctx, key, refreshToken, 7*24*time.Hour)
+	// role bisa berubah sejak token terakhir diterbitkan, ambil ulang dari database
+	user, err := u.userRepo.GetByID(ctx, session.UserID)
 	if err != nil {
+		u.unmarkRotated(ctx, claims)
+		return "", "", errors.New("user not found")
+	}
+
+	session.UserAgent = meta.UserAgent
+	session.IPAddress = meta.IPAddress
+	accessToken, newRefreshToken, err := u.issueTokens(ctx, user, session)
+	if err != nil {
+		// token baru gagal diterbitkan, token lama tetap boleh dipakai ulang agar klien tidak dianggap reuse
+		u.unmarkRotated(ctx, claims)
 		return "", "", err
 	}
+	return accessToken, newRefreshToken, nil
+}
 
-	return accessToken, refreshToken, nil
+type userUsecase struct {
+	userRepo iface.UserRepository
 }
 
-func (u *authUsecase) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
-	claims, err := jwt.ValidateToken(refreshToken)
-	if err != nil {
-		return "", "", errors.New("invalid refresh token")
+func NewUserUsecase(userRepo iface.UserRepository) iface.UserUsecase {
+	return &userUsecase{
+		userRepo: userRepo,
 	}
+}
 
-	userID := claims.UserID
-	key := "refresh_token:" + userID
+func (u *userUsecase) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
+	return u.userRepo.List(ctx, limit, offset)
+}
 
-	// Ambil refresh token dari Redis db 1
-	storedToken, err := u.cache0.Get(ctx, key)
-	if err != nil {
-		return "", "", errors.New("refresh token not found or expired")
+// EnsureAdmin membuat akun admin awal dari konfigurasi jika username belum ada, karena registrasi
+// publik tidak bisa membuat admin. Akun yang sudah ada tidak diubah.
+func (u *userUsecase) EnsureAdmin(ctx context.Context, username, email, password string) error {
+	if _, err := u.userRepo.GetByUsername(ctx, username); err == nil {
+		return nil
 	}
-
-	if storedToken != refreshToken {
-		return "", "", errors.New("refresh token does not match")
+	// batas panjang sama dengan validasi RegisterRequest
+	if len(password) < 6 {
+		return errors.New("admin password must be at least 6 characters")
 	}
 
-	newAccessToken, err := jwt.GenerateToken(userID, time.Minute*15)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", "", err
+		return err
 	}
+	return u.userRepo.Create(ctx, &entity.User{
+		Username: username,
+		Email:    email,
+		Password: string(hashedPassword),
+		Role:     entity.RoleAdmin,
+	})
+}
 
-	newRefreshToken, err := jwt.GenerateToken(userID, time.Hour*24*7)
+func (u *userUsecase) UpdateRole(ctx context.Context, id uuid.UUID, role string) (*entity.User, error) {
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return "", "", err
+		return nil, errors.New("user not found")
 	}
 
-	// Update refresh token di Redis
-	err = u.cache0.Set(ctx, key, newRefreshToken, 7*24*time.Hour)
-	if err != nil {
-		return "", "", err
+	user.Role = role
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, err
 	}
 
-	return newAccessToken, newRefreshToken, nil
+	return user, nil
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit menolak request dengan body di atas limit byte. Server berjalan dengan StreamRequestBody,
+// sehingga body di atas batas global belum dibaca ke memori saat middleware ini berjalan dan harus
+// dibatasi di sini. Body chunked (tanpa Content-Length) yang di-stream ditolak karena ukurannya tidak
+// bisa diperiksa di awal. Route di exempt ("METHOD /path") dilewati karena memasang BodyLimit sendiri.
+func BodyLimit(limit int, exempt ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		route := c.Method() + " " + strings.TrimSuffix(c.Path(), "/")
+		for _, e := range exempt {
+			if route == e {
+				return c.Next()
+			}
+		}
+
+		length := c.Request().Header.ContentLength()
+		if length > limit {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		if length < 0 && c.Request().IsBodyStream() {
+			return fiber.ErrLengthRequired
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeviceAuthMiddleware mengautentikasi perangkat dari header X-Device-Token dan menyimpannya di Locals("device")
+func DeviceAuthMiddleware(deviceUsecase iface.DeviceUseCase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get("X-Device-Token")
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing device token"})
+		}
+
+		device, err := deviceUsecase.AuthenticateToken(c.Context(), token)
+		if err != nil {
+			if errors.Is(err, entity.ErrInvalidDevice) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid device token"})
+			}
+			log.Printf("Failed to authenticate device token: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Unable to verify device token"})
+		}
+
+		c.Locals("device", device)
+		return c.Next()
+	}
+}
@@ -1,24 +1,85 @@
 package middleware
 
 import (
+	"errors"
+	"log"
+	"monitoring/internal/domain/entity"
+	iface "monitoring/internal/domain/interface"
 	"monitoring/pkg/jwt"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func JWTMiddleware() fiber.Handler {
+const (
+	// tokenTypeAPIKey menandai claims yang dibangun dari X-API-Key, bukan dari JWT
+	tokenTypeAPIKey = "api_key"
+	// streamPathPrefix adalah satu-satunya route yang menerima token di query string (?access_token=)
+	streamPathPrefix = "/api/v1/telemetry/stream/"
+)
+
+// JWTMiddleware menerima Bearer JWT atau header X-API-Key dan menyimpan claims di Locals("user")
+func JWTMiddleware(jwtService jwt.JwtService, authUsecase iface.AuthUsecase, apiKeyUsecase iface.APIKeyUseCase) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if rawKey := c.Get("X-API-Key"); rawKey != "" {
+			return authenticateAPIKey(c, apiKeyUsecase, rawKey)
+		}
+
 		token := c.Get("Authorization")
+		if token == "" && isStreamRequest(c) {
+			// Browser tidak bisa mengirim header Authorization untuk WebSocket/EventSource
+			token = c.Query("access_token")
+		}
 		if token == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing token"})
 		}
 
-		claims, err := jwt.ValidateToken(token)
-		if err != nil {
+		claims, err := jwtService.ValidateToken(token)
+		if err != nil || claims.TokenType != jwt.TokenTypeAccess {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
 		}
 
+		// Token yang sudah logout/dicabut ditolak, gagal cek Redis juga ditolak
+		revoked, err := authUsecase.IsTokenRevoked(c.Context(), claims.ID)
+		if err != nil {
+			log.Printf("Failed to check token denylist: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Unable to verify token"})
+		}
+		if revoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token has been revoked"})
+		}
+
 		c.Locals("user", claims)
 		return c.Next()
 	}
 }
+
+// authenticateAPIKey memakai role pemilik saat ini sebagai batas atas, scope key dicek oleh RequirePermission
+func authenticateAPIKey(c *fiber.Ctx, apiKeyUsecase iface.APIKeyUseCase, rawKey string) error {
+	key, err := apiKeyUsecase.Authenticate(c.Context(), rawKey)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidAPIKey) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid API key"})
+		}
+		log.Printf("Failed to authenticate api key: %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Unable to verify API key"})
+	}
+
+	c.Locals("user", &jwt.Claims{
+		UserID:    key.UserID.String(),
+		Role:      key.Owner.Role,
+		TokenType: tokenTypeAPIKey,
+	})
+	c.Locals("api_key", key)
+	return c.Next()
+}
+
+// isStreamRequest hanya berlaku untuk route stream telemetry agar token di URL (yang ikut tercatat
+// di access log) tidak diterima di route REST biasa
+func isStreamRequest(c *fiber.Ctx) bool {
+	if !strings.HasPrefix(c.Path(), streamPathPrefix) {
+		return false
+	}
+	return strings.EqualFold(c.Get("Upgrade"), "websocket") ||
+		strings.Contains(c.Get("Accept"), "text/event-stream")
+}
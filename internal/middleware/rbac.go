@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"monitoring/internal/domain/entity"
+	"monitoring/pkg/jwt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Permission string
+
+const (
+	PermDeviceRead    Permission = "devices:read"
+	PermDeviceWrite   Permission = "devices:write"
+	PermDeviceDelete  Permission = "devices:delete"
+	PermDeviceCommand Permission = "devices:command"
+	PermTelemetryRead Permission = "telemetry:read"
+	PermTelemetryPub  Permission = "telemetry:write"
+	PermUserRead      Permission = "users:read"
+	PermUserWrite     Permission = "users:write"
+	PermAlertRead     Permission = "alerts:read"
+	PermAlertWrite    Permission = "alerts:write"
+	PermNotifyRead    Permission = "notifications:read"
+	PermNotifyWrite   Permission = "notifications:write"
+	PermMetricWrite   Permission = "metrics:write"
+	PermSystemRead    Permission = "system:read"
+)
+
+// rolePermissions adalah matriks izin per role.
+// admin     : semua akses
+// user      : kelola perangkat & telemetry miliknya sendiri
+// operator  : baca/ubah perangkat, kirim perintah dan telemetry, tapi tidak bisa menghapus perangkat
+// viewer    : hanya baca
+var rolePermissions = map[string][]Permission{
+	entity.RoleAdmin: {
+		PermDeviceRead, PermDeviceWrite, PermDeviceDelete, PermDeviceCommand,
+		PermTelemetryRead, PermTelemetryPub,
+		PermUserRead, PermUserWrite,
+		PermAlertRead, PermAlertWrite,
+		PermNotifyRead, PermNotifyWrite,
+		PermMetricWrite, PermSystemRead,
+	},
+	entity.RoleUser: {
+		PermDeviceRead, PermDeviceWrite, PermDeviceDelete, PermDeviceCommand,
+		PermTelemetryRead, PermTelemetryPub,
+		PermAlertRead, PermAlertWrite,
+		PermNotifyRead, PermNotifyWrite,
+	},
+	entity.RoleOperator: {
+		PermDeviceRead, PermDeviceWrite, PermDeviceCommand,
+		PermTelemetryRead, PermTelemetryPub,
+		PermAlertRead, PermAlertWrite,
+		PermNotifyRead, PermNotifyWrite,
+	},
+	entity.RoleViewer: {
+		PermDeviceRead,
+		PermTelemetryRead,
+		PermAlertRead,
+		PermNotifyRead,
+	},
+}
+
+// scopePermissions memetakan scope API key ke permission. Izin efektif sebuah key adalah
+// irisan scope ini dengan permission role pemiliknya, sehingga key tidak pernah melebihi pemiliknya.
+var scopePermissions = map[string][]Permission{
+	entity.ScopeTelemetryRead:  {PermTelemetryRead},
+	entity.ScopeTelemetryWrite: {PermTelemetryPub},
+	entity.ScopeDevicesRead:    {PermDeviceRead},
+	entity.ScopeDevicesWrite:   {PermDeviceRead, PermDeviceWrite},
+	entity.ScopeDevicesAdmin:   {PermDeviceRead, PermDeviceWrite, PermDeviceDelete},
+	entity.ScopeDevicesCommand: {PermDeviceRead, PermDeviceCommand},
+	entity.ScopeAlertsRead:     {PermAlertRead},
+	entity.ScopeAlertsWrite:    {PermAlertRead, PermAlertWrite},
+}
+
+// ScopeAllows reports whether any of scopes grants perm.
+func ScopeAllows(scopes []string, perm Permission) bool {
+	for _, scope := range scopes {
+		for _, p := range scopePermissions[scope] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether role is granted perm by the permission matrix.
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole only lets the request through when the token's role is one of roles.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*jwt.Claims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+		}
+
+		for _, role := range roles {
+			if claims.Role == role {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+}
+
+// RequirePermission only lets the request through when the token's role has perm
+// and, for API key requests, one of the key's scopes grants perm.
+func RequirePermission(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*jwt.Claims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
+		}
+
+		if !HasPermission(claims.Role, perm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+
+		if key, ok := c.Locals("api_key").(*entity.APIKey); ok && !ScopeAllows(key.Scopes, perm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key scope does not allow this action"})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireUserToken menolak request yang diautentikasi dengan API key, dipakai untuk
+// endpoint pengelolaan sesi dan API key agar key yang bocor tidak bisa membuat key baru.
+func RequireUserToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("api_key").(*entity.APIKey); ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API keys cannot be used for this endpoint"})
+		}
+		return c.Next()
+	}
+}
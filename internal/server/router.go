@@ -1,40 +1,100 @@
 package server
 
 import (
+	"context"
+	"log"
 	"monitoring/config"
 	"monitoring/internal/domain/handler"
+	iface "monitoring/internal/domain/interface"
 	"monitoring/internal/domain/repository"
 	"monitoring/internal/domain/usecase"
 	"monitoring/internal/middleware"
 	"monitoring/pkg/db"
+	"monitoring/pkg/jwt"
+	"monitoring/pkg/spool"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/expvar"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"gorm.io/gorm"
 
 	database "monitoring/pkg/db"
 )
 
-func SetupRoutes(app *fiber.App, cfg *config.Config, db *gorm.DB, influx influxdb2.Client, redis0 *db.Client) {
+func SetupRoutes(app *fiber.App, cfg *config.Config, db *gorm.DB, influx influxdb2.Client, redis0 *db.Client, jwtService jwt.JwtService, artifactStore iface.ArtifactStore, telemetrySpool *spool.Spool) *database.MQTTClient {
 
-	monitoringRepo := repository.NewMonitoringRepository(influx, &cfg.InfluxDB)
+	// semua route memakai batas body default kecuali upload firmware yang dibatasi FIRMWARE_MAX_SIZE
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, "POST /api/v1/firmware"))
 
-	mqttClient := database.NewMQTTClient(cfg, monitoringRepo)
-	go mqttClient.Start()
+	monitoringRepo := repository.NewMonitoringRepository(influx, &cfg.InfluxDB, telemetrySpool)
 	var validate = validator.New()
 
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo, cfg.Webhook)
+	notificationHandler := handler.NewNotificationHandler(notificationUsecase, validate)
+	go notificationUsecase.Start(context.Background())
+
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+
 	autRepo := repository.NewUserRepository(db)
-	authUsecase := usecase.NewAuthUsecase(autRepo, redis0)
-	authHandler := handler.NewAuthHandler(authUsecase, validate)
+	authUsecase := usecase.NewAuthUsecase(autRepo, redis0, jwtService, notificationUsecase)
+	authHandler := handler.NewAuthHandler(authUsecase, jwtService, validate)
+	userUsecase := usecase.NewUserUsecase(autRepo)
+	if cfg.Admin.Username != "" {
+		if err := userUsecase.EnsureAdmin(context.Background(), cfg.Admin.Username, cfg.Admin.Email, cfg.Admin.Password); err != nil {
+			log.Printf("Failed to create initial admin %s: %v", cfg.Admin.Username, err)
+		}
+	}
+	userHandler := handler.NewUserHandler(userUsecase, validate)
 
 	devRepo := repository.NewDeviceRepository(db)
-	devUsecase := usecase.NewDeviceUsecase(devRepo, redis0)
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(apiKeyRepo, devRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUsecase, validate)
+	devUsecase := usecase.NewDeviceUsecase(devRepo, redis0, notificationUsecase)
 	deviceHandler := handler.NewDeviceHandler(devUsecase, validate)
+	go devUsecase.StartOfflineSweeper(context.Background(), cfg.Device.SweepInterval, cfg.Device.OfflineAfter)
+
+	alertRepo := repository.NewAlertRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+	alertUsecase := usecase.NewAlertUsecase(alertRepo, devRepo, groupRepo, notificationUsecase)
+	alertHandler := handler.NewAlertHandler(alertUsecase, validate)
+
+	metricRepo := repository.NewMetricRepository(db)
+	metricUsecase := usecase.NewMetricUsecase(metricRepo, redis0)
+	metricHandler := handler.NewMetricHandler(metricUsecase, validate)
+
+	monitoringUsecase := usecase.NewMonitoringUsecase(monitoringRepo, devRepo, metricRepo, redis0)
+	if telemetrySpool != nil {
+		go monitoringUsecase.StartSpoolReplayer(context.Background(), cfg.InfluxDB.SpoolReplayInterval)
+	}
+	groupUsecase := usecase.NewGroupUsecase(groupRepo, devRepo, monitoringRepo, metricRepo, redis0)
+	groupHandler := handler.NewGroupHandler(groupUsecase, validate)
+	provisioningUsecase := usecase.NewProvisioningUsecase(devRepo, devUsecase, redis0)
+	provisioningHandler := handler.NewProvisioningHandler(provisioningUsecase, validate)
 
-	telemetryHandler := handler.NewMQTTHandler(mqttClient, validate)
-	monitoringUsecase := usecase.NewMonitoringUsecase(monitoringRepo)
-	monitoringHandler := handler.NewMonitoringHandler(monitoringUsecase, validate)
+	mqttClient := database.NewMQTTClient(cfg, monitoringUsecase, alertUsecase, devUsecase, provisioningUsecase)
+	commandRepo := repository.NewCommandRepository(db)
+	commandUsecase := usecase.NewCommandUsecase(commandRepo, devRepo, mqttClient)
+	commandHandler := handler.NewCommandHandler(commandUsecase, validate)
+	mqttClient.SetCommandUsecase(commandUsecase)
+	shadowRepo := repository.NewShadowRepository(db)
+	shadowUsecase := usecase.NewShadowUsecase(shadowRepo, devRepo, mqttClient)
+	shadowHandler := handler.NewShadowHandler(shadowUsecase, validate)
+	mqttClient.SetShadowUsecase(shadowUsecase)
+	firmwareRepo := repository.NewFirmwareRepository(db)
+	firmwareUsecase := usecase.NewFirmwareUsecase(firmwareRepo, devRepo, groupRepo, artifactStore, mqttClient, notificationUsecase, cfg.Firmware.BaseURL)
+	firmwareHandler := handler.NewFirmwareHandler(firmwareUsecase, validate)
+	mqttClient.SetFirmwareUsecase(firmwareUsecase)
+	go commandUsecase.StartTimeoutSweeper(context.Background(), cfg.Device.CommandSweepInterval)
+	go mqttClient.Start()
+
+	telemetryHandler := handler.NewMQTTHandler(mqttClient, devUsecase, validate)
+	monitoringHandler := handler.NewMonitoringHandler(monitoringUsecase, authUsecase, validate)
+	ingestHandler := handler.NewIngestHandler(monitoringUsecase, devUsecase, alertUsecase, validate)
+
+	// Public key untuk verifikasi token oleh service lain
+	app.Get("/.well-known/jwks.json", authHandler.JWKS)
 
 	api := app.Group("/api/v1")
 	// Auth routes
@@ -43,20 +103,120 @@ func SetupRoutes(app *fiber.App, cfg *config.Config, db *gorm.DB, influx influxd
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh", authHandler.RefreshToken)
 
+	// Device ingest, diautentikasi dengan token perangkat (bukan JWT user)
+	api.Post("/ingest", middleware.DeviceAuthMiddleware(devUsecase), ingestHandler.Ingest)
+
+	// Zero-touch provisioning, dipanggil perangkat sebelum memiliki kredensial
+	api.Post("/provision", provisioningHandler.RequestProvision)
+	api.Get("/provision/:id", provisioningHandler.PollProvision)
+
+	// Download firmware OTA, diautentikasi dengan token perangkat
+	api.Get("/ota/firmware/:id", middleware.DeviceAuthMiddleware(devUsecase), firmwareHandler.DownloadFirmware)
+
+	// Counter internal (expvar) seperti statistik penulisan InfluxDB, hanya untuk admin
+	app.Get("/debug/vars", middleware.JWTMiddleware(jwtService, authUsecase, apiKeyUsecase), middleware.RequirePermission(middleware.PermSystemRead), expvar.New())
+
 	// Protected routes
-	protected := api.Use(middleware.JWTMiddleware())
+	protected := api.Use(middleware.JWTMiddleware(jwtService, authUsecase, apiKeyUsecase))
+
+	// Session routes
+	sessions := protected.Group("/auth", middleware.RequireUserToken())
+	sessions.Post("/logout", authHandler.Logout)
+	sessions.Post("/logout-all", authHandler.LogoutAll)
+	sessions.Get("/sessions", authHandler.ListSessions)
+	sessions.Delete("/sessions/:id", authHandler.RevokeSession)
+
+	// API key routes
+	apiKeys := protected.Group("/api-keys", middleware.RequireUserToken())
+	apiKeys.Post("/", apiKeyHandler.CreateAPIKey)
+	apiKeys.Get("/", apiKeyHandler.ListAPIKeys)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeAPIKey)
 
 	// // Device routes
 	devices := protected.Group("/devices")
-	devices.Post("/", deviceHandler.CreateDevice)
-	devices.Get("/", deviceHandler.GetDevice)
-	// devices.Get("/:id", deviceHandler.GetDevice)
-	// devices.Put("/:id", deviceHandler.UpdateDevice)
-	// devices.Delete("/:id", deviceHandler.DeleteDevice)
+	devices.Post("/", middleware.RequirePermission(middleware.PermDeviceWrite), deviceHandler.CreateDevice)
+	devices.Get("/", middleware.RequirePermission(middleware.PermDeviceRead), deviceHandler.ListDevices)
+	devices.Post("/claim", middleware.RequirePermission(middleware.PermDeviceWrite), provisioningHandler.ClaimDevice)
+	devices.Get("/:id", middleware.RequirePermission(middleware.PermDeviceRead), deviceHandler.GetDevice)
+	devices.Patch("/:id", middleware.RequirePermission(middleware.PermDeviceWrite), deviceHandler.UpdateDevice)
+	devices.Delete("/:id", middleware.RequirePermission(middleware.PermDeviceDelete), deviceHandler.DeleteDevice)
+	devices.Post("/:id/token", middleware.RequirePermission(middleware.PermDeviceWrite), deviceHandler.RotateDeviceToken)
+	devices.Post("/:id/commands", middleware.RequirePermission(middleware.PermDeviceCommand), commandHandler.SendCommand)
+	devices.Get("/:id/commands", middleware.RequirePermission(middleware.PermDeviceRead), commandHandler.ListCommands)
+	devices.Get("/:id/commands/:command_id", middleware.RequirePermission(middleware.PermDeviceRead), commandHandler.GetCommand)
+	devices.Get("/:id/shadow", middleware.RequirePermission(middleware.PermDeviceRead), shadowHandler.GetShadow)
+	devices.Patch("/:id/shadow/desired", middleware.RequirePermission(middleware.PermDeviceWrite), shadowHandler.UpdateDesired)
+
+	// Device group routes
+	groups := protected.Group("/groups")
+	groups.Post("/", middleware.RequirePermission(middleware.PermDeviceWrite), groupHandler.CreateGroup)
+	groups.Get("/", middleware.RequirePermission(middleware.PermDeviceRead), groupHandler.ListGroups)
+	groups.Get("/:id", middleware.RequirePermission(middleware.PermDeviceRead), groupHandler.GetGroup)
+	groups.Put("/:id", middleware.RequirePermission(middleware.PermDeviceWrite), groupHandler.UpdateGroup)
+	groups.Delete("/:id", middleware.RequirePermission(middleware.PermDeviceWrite), groupHandler.DeleteGroup)
+	groups.Post("/:id/devices", middleware.RequirePermission(middleware.PermDeviceWrite), groupHandler.AddDevices)
+	groups.Delete("/:id/devices/:device_id", middleware.RequirePermission(middleware.PermDeviceWrite), groupHandler.RemoveDevice)
+	groups.Get("/:id/telemetry/series", middleware.RequirePermission(middleware.PermTelemetryRead), groupHandler.GetGroupSeries)
+
+	// Firmware & OTA rollout routes
+	firmware := protected.Group("/firmware")
+	// tambahan 1MB untuk field multipart selain file
+	firmware.Post("/", middleware.BodyLimit(cfg.Firmware.MaxSize+1<<20), middleware.RequirePermission(middleware.PermDeviceWrite), firmwareHandler.UploadFirmware)
+	firmware.Get("/", middleware.RequirePermission(middleware.PermDeviceRead), firmwareHandler.ListFirmware)
+	firmware.Post("/rollouts", middleware.RequirePermission(middleware.PermDeviceWrite), firmwareHandler.CreateRollout)
+	firmware.Get("/rollouts", middleware.RequirePermission(middleware.PermDeviceRead), firmwareHandler.ListRollouts)
+	firmware.Get("/rollouts/:id", middleware.RequirePermission(middleware.PermDeviceRead), firmwareHandler.GetRollout)
+	firmware.Post("/rollouts/:id/halt", middleware.RequirePermission(middleware.PermDeviceWrite), firmwareHandler.HaltRollout)
+	firmware.Post("/rollouts/:id/resume", middleware.RequirePermission(middleware.PermDeviceWrite), firmwareHandler.ResumeRollout)
+	firmware.Post("/rollouts/:id/cancel", middleware.RequirePermission(middleware.PermDeviceWrite), firmwareHandler.CancelRollout)
+	firmware.Get("/:id", middleware.RequirePermission(middleware.PermDeviceRead), firmwareHandler.GetFirmware)
+	firmware.Delete("/:id", middleware.RequirePermission(middleware.PermDeviceDelete), firmwareHandler.DeleteFirmware)
 
 	// // Telemetry routes
 	telemetry := protected.Group("/telemetry")
-	telemetry.Get("/device/:device_id", monitoringHandler.GetMonitoringByDeviceID)
-	// telemetry.Get("/device/:deviceId/latest", telemetryHandler.GetLatestTelemetry)
-	telemetry.Post("/:id", telemetryHandler.TriggerMQTT)
+	telemetry.Get("/device/:device_id", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.GetMonitoringByDeviceID)
+	telemetry.Get("/device/:device_id/series", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.GetMonitoringSeries)
+	telemetry.Get("/device/:device_id/stats", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.GetMonitoringStats)
+	telemetry.Get("/device/:device_id/latest", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.GetLatestMonitoring)
+	telemetry.Get("/latest", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.GetLatestForOwnedDevices)
+	telemetry.Get("/stream/ws", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.StreamUpgrade, monitoringHandler.StreamWebSocket())
+	telemetry.Get("/stream/sse", middleware.RequirePermission(middleware.PermTelemetryRead), monitoringHandler.StreamSSE)
+	telemetry.Post("/:id", middleware.RequirePermission(middleware.PermTelemetryPub), telemetryHandler.TriggerMQTT)
+
+	// Metric catalog routes, katalog berlaku global sehingga hanya admin yang bisa mengubah
+	metrics := protected.Group("/metrics")
+	metrics.Get("/", middleware.RequirePermission(middleware.PermTelemetryRead), metricHandler.ListMetrics)
+	metrics.Post("/", middleware.RequirePermission(middleware.PermMetricWrite), metricHandler.CreateMetric)
+	metrics.Put("/:id", middleware.RequirePermission(middleware.PermMetricWrite), metricHandler.UpdateMetric)
+	metrics.Delete("/:id", middleware.RequirePermission(middleware.PermMetricWrite), metricHandler.DeleteMetric)
+
+	// System routes, kondisi internal server untuk admin
+	system := protected.Group("/system")
+	system.Get("/spool", middleware.RequirePermission(middleware.PermSystemRead), monitoringHandler.GetSpoolStats)
+
+	// Alert routes
+	alerts := protected.Group("/alerts")
+	alerts.Get("/", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.ListAlerts)
+	alerts.Post("/rules", middleware.RequirePermission(middleware.PermAlertWrite), alertHandler.CreateRule)
+	alerts.Get("/rules", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.ListRules)
+	alerts.Get("/rules/:id", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetRule)
+	alerts.Put("/rules/:id", middleware.RequirePermission(middleware.PermAlertWrite), alertHandler.UpdateRule)
+	alerts.Delete("/rules/:id", middleware.RequirePermission(middleware.PermAlertWrite), alertHandler.DeleteRule)
+
+	// Notification routes
+	notifications := protected.Group("/notifications")
+	notifications.Post("/channels", middleware.RequirePermission(middleware.PermNotifyWrite), notificationHandler.CreateChannel)
+	notifications.Get("/channels", middleware.RequirePermission(middleware.PermNotifyRead), notificationHandler.ListChannels)
+	notifications.Get("/channels/:id", middleware.RequirePermission(middleware.PermNotifyRead), notificationHandler.GetChannel)
+	notifications.Put("/channels/:id", middleware.RequirePermission(middleware.PermNotifyWrite), notificationHandler.UpdateChannel)
+	notifications.Delete("/channels/:id", middleware.RequirePermission(middleware.PermNotifyWrite), notificationHandler.DeleteChannel)
+	notifications.Get("/channels/:id/deliveries", middleware.RequirePermission(middleware.PermNotifyRead), notificationHandler.ListDeliveries)
+	notifications.Post("/channels/:id/test", middleware.RequirePermission(middleware.PermNotifyWrite), notificationHandler.TestChannel)
+
+	// User management routes
+	users := protected.Group("/users")
+	users.Get("/", middleware.RequirePermission(middleware.PermUserRead), userHandler.ListUsers)
+	users.Put("/:id/role", middleware.RequirePermission(middleware.PermUserWrite), userHandler.UpdateRole)
+
+	return mqttClient
 }
@@ -8,7 +8,14 @@ import (
 )
 
 func NewInfluxDB(cfg *config.Config) (influxdb2.Client, error) {
-	client := influxdb2.NewClient(cfg.InfluxDB.URL, cfg.InfluxDB.Token)
+	options := influxdb2.DefaultOptions().
+		SetBatchSize(uint(cfg.InfluxDB.BatchSize)).
+		SetFlushInterval(uint(cfg.InfluxDB.FlushInterval.Milliseconds())).
+		SetMaxRetries(uint(cfg.InfluxDB.MaxRetries)).
+		SetRetryInterval(uint(cfg.InfluxDB.RetryInterval.Milliseconds())).
+		SetMaxRetryInterval(uint(cfg.InfluxDB.MaxRetryInterval.Milliseconds())).
+		SetRetryBufferLimit(uint(cfg.InfluxDB.RetryBufferLimit))
+	client := influxdb2.NewClientWithOptions(cfg.InfluxDB.URL, cfg.InfluxDB.Token, options)
 
 	// Test connection
 	_, err := client.Health(context.Background())
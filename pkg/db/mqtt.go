@@ -8,20 +8,43 @@ import (
 	"monitoring/config"
 	"monitoring/internal/domain/entity"
 	iface "monitoring/internal/domain/interface"
+	"monitoring/pkg/utils"
 	"strings"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
+const (
+	// telemetryMaxClockSkew adalah selisih maksimal timestamp perangkat di depan jam server
+	telemetryMaxClockSkew = 5 * time.Minute
+	// maxProvisionWaiters membatasi goroutine yang menunggu klaim provisioning lewat MQTT
+	maxProvisionWaiters = 256
+	// progres OTA diproses di luar callback karena laporan terakhir satu stage memicu publish ke stage berikutnya
+	otaProgressWorkers   = 2
+	otaProgressQueueSize = 1024
+	otaProgressBlock     = time.Second
+)
+
 type MQTTClient struct {
-	client         mqtt.Client
-	monitoringRepo iface.MonitoringRepository
-	topic          string
+	client              mqtt.Client
+	monitoringUsecase   iface.MonitoringUseCase
+	alertUsecase        iface.AlertUseCase
+	deviceUsecase       iface.DeviceUseCase
+	provisioningUsecase iface.ProvisioningUseCase
+	commandUsecase      iface.CommandUseCase
+	shadowUsecase       iface.ShadowUseCase
+	firmwareUsecase     iface.FirmwareUseCase
+	topic               string
+	ingest              *ingestPool
+	otaProgress         *ingestPool
+	validate            *validator.Validate
+	provisionWaiters    chan struct{}
 }
 
-func NewMQTTClient(cfg *config.Config, repo iface.MonitoringRepository) *MQTTClient {
+func NewMQTTClient(cfg *config.Config, monitoringUsecase iface.MonitoringUseCase, alertUsecase iface.AlertUseCase, deviceUsecase iface.DeviceUseCase, provisioningUsecase iface.ProvisioningUseCase) *MQTTClient {
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(fmt.Sprintf("tcp://%s:%s", cfg.MQTT.Broker, cfg.MQTT.Port))
 	opts.SetClientID("iot_monitoring_server")
@@ -33,11 +56,35 @@ func NewMQTTClient(cfg *config.Config, repo iface.MonitoringRepository) *MQTTCli
 
 	client := mqtt.NewClient(opts)
 
-	return &MQTTClient{
-		client:         client,
-		topic:          cfg.MQTT.Topic,
-		monitoringRepo: repo,
+	m := &MQTTClient{
+		client:              client,
+		topic:               cfg.MQTT.Topic,
+		monitoringUsecase:   monitoringUsecase,
+		alertUsecase:        alertUsecase,
+		deviceUsecase:       deviceUsecase,
+		provisioningUsecase: provisioningUsecase,
+		validate:            validator.New(),
+		provisionWaiters:    make(chan struct{}, maxProvisionWaiters),
 	}
+	m.ingest = newIngestPool(&cfg.MQTT, m.processTelemetry)
+	m.otaProgress = newWorkerPool(otaProgressWorkers, otaProgressQueueSize, otaProgressBlock, otaProgressStats, m.processOTAProgress)
+	return m
+}
+
+// SetCommandUsecase dipanggil sebelum Start, terpisah dari constructor karena command usecase
+// membutuhkan client ini sebagai publisher
+func (m *MQTTClient) SetCommandUsecase(commandUsecase iface.CommandUseCase) {
+	m.commandUsecase = commandUsecase
+}
+
+// SetShadowUsecase dipanggil sebelum Start, alasannya sama dengan SetCommandUsecase
+func (m *MQTTClient) SetShadowUsecase(shadowUsecase iface.ShadowUseCase) {
+	m.shadowUsecase = shadowUsecase
+}
+
+// SetFirmwareUsecase dipanggil sebelum Start, alasannya sama dengan SetCommandUsecase
+func (m *MQTTClient) SetFirmwareUsecase(firmwareUsecase iface.FirmwareUseCase) {
+	m.firmwareUsecase = firmwareUsecase
 }
 
 func (m *MQTTClient) Start() {
@@ -48,6 +95,9 @@ func (m *MQTTClient) Start() {
 
 	log.Println("Connected to MQTT broker")
 
+	m.ingest.start()
+	m.otaProgress.start()
+
 	// Subscribe to telemetry topic
 	if token := m.client.Subscribe(m.topic+"/+/telemetry", 1, m.handleTelemetryMessage); token.Wait() && token.Error() != nil {
 		log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
@@ -55,33 +105,356 @@ func (m *MQTTClient) Start() {
 	}
 
 	log.Printf("Subscribed to MQTT topic: %s/+/telemetry", m.topic)
-}
 
-func (m *MQTTClient) handleTelemetryMessage(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("Received MQTT message: %s", msg.Payload())
+	// Subscribe to status topic, perangkat memasang Last Will "offline" di topic ini
+	if token := m.client.Subscribe(m.topic+"/+/status", 1, m.handleStatusMessage); token.Wait() && token.Error() != nil {
+		log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
+		return
+	}
 
-	var telemetry entity.MonitoringData
-	if err := json.Unmarshal(msg.Payload(), &telemetry); err != nil {
-		log.Printf("Failed to parse telemetry data: %v", err)
+	log.Printf("Subscribed to MQTT topic: %s/+/status", m.topic)
+
+	// Subscribe to provisioning bootstrap topic untuk perangkat yang belum terdaftar
+	if token := m.client.Subscribe(m.topic+"/provision/request", 1, m.handleProvisionMessage); token.Wait() && token.Error() != nil {
+		log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
 		return
 	}
 
-	// 	// Extract device ID from topic (topic format: iot/monitoring/{device_id}/telemetry)
-	topicParts := strings.Split(msg.Topic(), "/")
-	if len(topicParts) >= 3 {
-		id, err := uuid.Parse(topicParts[2])
-		if err != nil {
-			log.Printf("Invalid UUID in topic: %v", err)
+	log.Printf("Subscribed to MQTT topic: %s/provision/request", m.topic)
+
+	// Subscribe to command response topic, perangkat membalas perintah dengan correlation_id
+	if m.commandUsecase != nil {
+		if token := m.client.Subscribe(m.topic+"/+/commands/response", 1, m.handleCommandResponse); token.Wait() && token.Error() != nil {
+			log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
+			return
+		}
+
+		log.Printf("Subscribed to MQTT topic: %s/+/commands/response", m.topic)
+	}
+
+	// Subscribe to shadow topics: update berisi reported state, get meminta delta terkini
+	if m.shadowUsecase != nil {
+		if token := m.client.Subscribe(m.topic+"/+/shadow/update", 1, m.handleShadowUpdate); token.Wait() && token.Error() != nil {
+			log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
+			return
+		}
+
+		log.Printf("Subscribed to MQTT topic: %s/+/shadow/update", m.topic)
+
+		if token := m.client.Subscribe(m.topic+"/+/shadow/get", 1, m.handleShadowGet); token.Wait() && token.Error() != nil {
+			log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
 			return
 		}
-		telemetry.DeviceID = id
+
+		log.Printf("Subscribed to MQTT topic: %s/+/shadow/get", m.topic)
 	}
 
-	telemetry.Timestamp = time.Now()
+	// Subscribe to OTA progress topic
+	if m.firmwareUsecase != nil {
+		if token := m.client.Subscribe(m.topic+"/+/ota/progress", 1, m.handleOTAProgress); token.Wait() && token.Error() != nil {
+			log.Printf("Failed to subscribe to MQTT topic: %v", token.Error())
+			return
+		}
+
+		log.Printf("Subscribed to MQTT topic: %s/+/ota/progress", m.topic)
+	}
+}
+
+// Stop memutus koneksi broker lalu menunggu worker telemetry menghabiskan antrean,
+// dipanggil saat shutdown sebelum writer InfluxDB ditutup
+func (m *MQTTClient) Stop() {
+	if m.client.IsConnected() {
+		m.client.Disconnect(250)
+	}
+	m.ingest.stop()
+	m.otaProgress.stop()
+}
+
+// handleTelemetryMessage hanya meneruskan pesan ke worker pool agar penulisan yang lambat
+// tidak menahan callback MQTT lain
+func (m *MQTTClient) handleTelemetryMessage(client mqtt.Client, msg mqtt.Message) {
+	// Extract device ID from topic (topic format: iot/monitoring/{device_id}/telemetry)
+	id, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	if !m.ingest.submit(&deviceMessage{deviceID: id, payload: msg.Payload()}) {
+		log.Printf("Telemetry queue full, dropping message from %s", id)
+	}
+}
+
+// processTelemetry dijalankan worker pool, pesan dari perangkat yang sama diproses berurutan
+func (m *MQTTClient) processTelemetry(msg *deviceMessage) {
+	var telemetry entity.MonitoringData
+	if err := json.Unmarshal(msg.payload, &telemetry); err != nil {
+		ingestStats.Add("invalid", 1)
+		log.Printf("Failed to parse telemetry data from %s: %v", msg.deviceID, err)
+		return
+	}
+	telemetry.DeviceID = msg.deviceID
+
+	// timestamp dari perangkat dipakai agar data yang sempat di-buffer agent tersimpan di waktu aslinya,
+	// kosong atau terlalu jauh di depan jam server diganti waktu server
+	now := time.Now()
+	if telemetry.Timestamp.IsZero() || telemetry.Timestamp.After(now.Add(telemetryMaxClockSkew)) {
+		telemetry.Timestamp = now
+	}
 	ctx := context.Background()
-	if err := m.monitoringRepo.Store(ctx, &telemetry); err != nil {
+	if err := m.monitoringUsecase.StoreMonitoringData(ctx, &telemetry); err != nil {
+		ingestStats.Add("failed", 1)
 		log.Printf("Failed to save telemetry data: %v", err)
 	}
+
+	if err := m.deviceUsecase.Heartbeat(ctx, telemetry.DeviceID); err != nil {
+		log.Printf("Failed to update device heartbeat: %v", err)
+	}
+
+	if err := m.alertUsecase.Evaluate(ctx, &telemetry); err != nil {
+		log.Printf("Failed to evaluate alert rules: %v", err)
+	}
+}
+
+// handleStatusMessage menerima payload "online"/"offline" (atau {"status": "..."}) di topic {topic}/{device_id}/status
+func (m *MQTTClient) handleStatusMessage(client mqtt.Client, msg mqtt.Message) {
+	deviceID, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	status := strings.TrimSpace(string(msg.Payload()))
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(msg.Payload(), &body); err == nil && body.Status != "" {
+		status = body.Status
+	}
+
+	var isOnline bool
+	switch strings.ToLower(status) {
+	case "online":
+		isOnline = true
+	case "offline":
+		isOnline = false
+	default:
+		log.Printf("Unknown device status %q on %s", status, msg.Topic())
+		return
+	}
+
+	if err := m.deviceUsecase.ReportStatus(context.Background(), deviceID, isOnline); err != nil {
+		log.Printf("Failed to update device status: %v", err)
+	}
+}
+
+// handleProvisionMessage menerima entity.ProvisionRequest di {topic}/provision/request.
+// Claim code dibalas di {topic}/provision/{provision_id}/claim_code dan, setelah user mengklaim,
+// kredensial perangkat dikirim di {topic}/provision/{provision_id}/credentials. Kedua balasan berupa
+// entity.SealedMessage yang hanya bisa dibuka dengan private key pasangan public_key perangkat.
+func (m *MQTTClient) handleProvisionMessage(client mqtt.Client, msg mqtt.Message) {
+	var req entity.ProvisionRequest
+	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
+		log.Printf("Failed to parse provisioning request: %v", err)
+		return
+	}
+	if err := m.validate.Struct(&req); err != nil {
+		log.Printf("Invalid provisioning request from %s: %v", req.MacAddress, err)
+		return
+	}
+	// provision_id menjadi bagian topic balasan, jadi wajib diisi perangkat
+	if req.ProvisionID == "" || req.PublicKey == "" {
+		log.Printf("Provisioning request without provision_id or public_key from %s", req.MacAddress)
+		return
+	}
+
+	// request, penantian klaim dan publish berjalan di luar callback paho agar router MQTT tidak tertahan
+	select {
+	case m.provisionWaiters <- struct{}{}:
+	default:
+		log.Printf("Too many pending MQTT provisioning requests, dropping request from %s", req.MacAddress)
+		return
+	}
+	go func() {
+		defer func() { <-m.provisionWaiters }()
+		m.provision(&req)
+	}()
+}
+
+func (m *MQTTClient) provision(req *entity.ProvisionRequest) {
+	ctx := context.Background()
+	ticket, err := m.provisioningUsecase.Request(ctx, req)
+	if err != nil {
+		log.Printf("Failed to create provisioning request for %s: %v", req.MacAddress, err)
+		return
+	}
+
+	replyTopic := fmt.Sprintf("%s/provision/%s", m.topic, ticket.ProvisionID)
+	m.publishSealed(replyTopic+"/claim_code", req.PublicKey, ticket.ProvisionID, ticket)
+
+	status, err := m.provisioningUsecase.WaitForClaim(ctx, ticket.ProvisionID)
+	if err != nil {
+		log.Printf("Provisioning %s not completed: %v", ticket.ProvisionID, err)
+		return
+	}
+	// Poll sudah mengenkripsi kredensial karena request menyertakan public_key
+	m.publishJSON(replyTopic+"/credentials", &entity.SealedMessage{ProvisionID: ticket.ProvisionID, Sealed: status.SealedCredential})
+}
+
+func (m *MQTTClient) publishSealed(topic, publicKey, provisionID string, v interface{}) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode MQTT payload for %s: %v", topic, err)
+		return
+	}
+	sealed, err := utils.SealToPublicKey(publicKey, payload)
+	if err != nil {
+		log.Printf("Failed to seal MQTT payload for %s: %v", topic, err)
+		return
+	}
+	m.publishJSON(topic, &entity.SealedMessage{ProvisionID: provisionID, Sealed: sealed})
+}
+
+// handleCommandResponse menerima entity.CommandResponse di {topic}/{device_id}/commands/response
+func (m *MQTTClient) handleCommandResponse(client mqtt.Client, msg mqtt.Message) {
+	deviceID, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	var resp entity.CommandResponse
+	if err := json.Unmarshal(msg.Payload(), &resp); err != nil {
+		log.Printf("Failed to parse command response: %v", err)
+		return
+	}
+
+	if err := m.commandUsecase.HandleResponse(context.Background(), deviceID, &resp); err != nil {
+		log.Printf("Failed to handle command response from %s: %v", deviceID, err)
+	}
+}
+
+// PublishCommand mengirim perintah ke {topic}/{device_id}/commands
+func (m *MQTTClient) PublishCommand(deviceID uuid.UUID, msg *entity.CommandMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return m.PublishTelemetry(fmt.Sprintf("%s/%s/commands", m.topic, deviceID), payload)
+}
+
+// handleShadowUpdate menerima {"state": {...}} di {topic}/{device_id}/shadow/update sebagai reported state
+func (m *MQTTClient) handleShadowUpdate(client mqtt.Client, msg mqtt.Message) {
+	deviceID, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	var body struct {
+		State map[string]interface{} `json:"state"`
+	}
+	if err := json.Unmarshal(msg.Payload(), &body); err != nil || body.State == nil {
+		log.Printf("Invalid shadow update from %s", deviceID)
+		return
+	}
+
+	if _, err := m.shadowUsecase.ReportState(context.Background(), deviceID, body.State); err != nil {
+		log.Printf("Failed to update reported shadow of %s: %v", deviceID, err)
+	}
+}
+
+func (m *MQTTClient) handleShadowGet(client mqtt.Client, msg mqtt.Message) {
+	deviceID, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	if err := m.shadowUsecase.SyncDelta(context.Background(), deviceID); err != nil {
+		log.Printf("Failed to sync shadow delta of %s: %v", deviceID, err)
+	}
+}
+
+// PublishShadowDelta mengirim delta ke {topic}/{device_id}/shadow/delta sebagai retained message
+// agar perangkat yang baru tersambung langsung menerimanya. Dipanggil juga dari callback shadow
+// update/get, sehingga tidak menunggu token publish; kegagalan kirim hanya dicatat di log.
+func (m *MQTTClient) PublishShadowDelta(deviceID uuid.UUID, delta *entity.ShadowDelta) error {
+	payload, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+	m.publishAsync(fmt.Sprintf("%s/%s/shadow/delta", m.topic, deviceID), true, payload)
+	return nil
+}
+
+// handleOTAProgress menerima entity.OTAProgress di {topic}/{device_id}/ota/progress dan meneruskannya
+// ke worker, karena ReportProgress bisa merilis stage berikutnya dan menunggu publish OTA ke setiap perangkat
+func (m *MQTTClient) handleOTAProgress(client mqtt.Client, msg mqtt.Message) {
+	deviceID, err := m.deviceIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("Invalid UUID in topic: %v", err)
+		return
+	}
+
+	if !m.otaProgress.submit(&deviceMessage{deviceID: deviceID, payload: msg.Payload()}) {
+		log.Printf("OTA progress queue full, dropping message from %s", deviceID)
+	}
+}
+
+func (m *MQTTClient) processOTAProgress(msg *deviceMessage) {
+	var progress entity.OTAProgress
+	if err := json.Unmarshal(msg.payload, &progress); err != nil {
+		log.Printf("Failed to parse OTA progress: %v", err)
+		return
+	}
+
+	if err := m.firmwareUsecase.ReportProgress(context.Background(), msg.deviceID, &progress); err != nil {
+		log.Printf("Failed to handle OTA progress from %s: %v", msg.deviceID, err)
+	}
+}
+
+// PublishOTA mengirim notifikasi ke {topic}/{device_id}/ota sebagai retained message agar perangkat
+// yang sedang offline menerimanya saat tersambung. notification nil menghapus retained message.
+// Menunggu token publish sehingga tidak boleh dipanggil langsung dari callback paho.
+func (m *MQTTClient) PublishOTA(deviceID uuid.UUID, notification *entity.OTANotification) error {
+	var payload []byte
+	if notification != nil {
+		var err error
+		if payload, err = json.Marshal(notification); err != nil {
+			return err
+		}
+	}
+	token := m.client.Publish(fmt.Sprintf("%s/%s/ota", m.topic, deviceID), 1, true, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// publishJSON tidak menunggu token publish sehingga aman dipanggil dari callback paho
+func (m *MQTTClient) publishJSON(topic string, v interface{}) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode MQTT payload for %s: %v", topic, err)
+		return
+	}
+	m.publishAsync(topic, false, payload)
+}
+
+// publishAsync mengirim pesan QoS 1 tanpa memblok pemanggil, hasilnya hanya dicatat di log.
+// Callback paho tidak boleh menunggu token karena router pesan berjalan di goroutine yang sama.
+func (m *MQTTClient) publishAsync(topic string, retained bool, payload []byte) {
+	token := m.client.Publish(topic, 1, retained, payload)
+	go func() {
+		if token.Wait() && token.Error() != nil {
+			log.Printf("Failed to publish MQTT message to %s: %v", topic, token.Error())
+		}
+	}()
+}
+
+// deviceIDFromTopic mengambil device_id dari topic {topic}/{device_id}/...
+func (m *MQTTClient) deviceIDFromTopic(topic string) (uuid.UUID, error) {
+	rest := strings.TrimPrefix(topic, m.topic+"/")
+	return uuid.Parse(strings.SplitN(rest, "/", 2)[0])
 }
 
 func (m *MQTTClient) PublishTelemetry(topic string, payload []byte) error {
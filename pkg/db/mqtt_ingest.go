@@ -0,0 +1,161 @@
+package db
+
+import (
+	"expvar"
+	"hash/fnv"
+	"log"
+	"monitoring/config"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	IngestPolicyBlock = "block"
+	IngestPolicyDrop  = "drop"
+)
+
+// statistik pool dipublikasikan lewat expvar (/debug/vars)
+var (
+	ingestStats      = expvar.NewMap("mqtt_ingest")
+	otaProgressStats = expvar.NewMap("mqtt_ota_progress")
+)
+
+type deviceMessage struct {
+	deviceID uuid.UUID
+	payload  []byte
+}
+
+// ingestPool memisahkan callback MQTT dari pemrosesan pesan perangkat (telemetry, progres OTA).
+// Setiap worker punya antrean sendiri dan perangkat dipetakan ke worker lewat hash device_id, sehingga
+// pesan satu perangkat diproses berurutan sementara perangkat lain tetap berjalan paralel.
+type ingestPool struct {
+	queues       []chan *deviceMessage
+	blockTimeout time.Duration // 0 berarti policy drop
+	handle       func(msg *deviceMessage)
+	stats        *expvar.Map
+	once         sync.Once
+	wg           sync.WaitGroup
+
+	// mu melindungi queues dari submit setelah stop menutup channel
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newIngestPool(cfg *config.MQTTConfig, handle func(msg *deviceMessage)) *ingestPool {
+	var blockTimeout time.Duration
+	switch cfg.IngestFullPolicy {
+	case IngestPolicyDrop, "":
+	case IngestPolicyBlock:
+		blockTimeout = cfg.IngestBlockTimeout
+	default:
+		log.Printf("Unknown MQTT ingest policy %q, using %s", cfg.IngestFullPolicy, IngestPolicyDrop)
+	}
+	return newWorkerPool(cfg.IngestWorkers, cfg.IngestQueueSize, blockTimeout, ingestStats, handle)
+}
+
+func newWorkerPool(workers, queueSize int, blockTimeout time.Duration, stats *expvar.Map, handle func(msg *deviceMessage)) *ingestPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	perWorker := queueSize / workers
+	if perWorker <= 0 {
+		perWorker = 1
+	}
+
+	p := &ingestPool{
+		queues:       make([]chan *deviceMessage, workers),
+		blockTimeout: blockTimeout,
+		handle:       handle,
+		stats:        stats,
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan *deviceMessage, perWorker)
+	}
+	stats.Set("queue_depth", expvar.Func(func() interface{} { return p.depth() }))
+	return p
+}
+
+// start menjalankan worker, aman dipanggil berulang (misalnya saat Start dipanggil ulang)
+func (p *ingestPool) start() {
+	p.once.Do(func() {
+		for _, queue := range p.queues {
+			p.wg.Add(1)
+			go p.work(queue)
+		}
+	})
+}
+
+func (p *ingestPool) work(queue <-chan *deviceMessage) {
+	defer p.wg.Done()
+	for msg := range queue {
+		p.handle(msg)
+		p.stats.Add("processed", 1)
+	}
+}
+
+// stop menolak pesan baru, menutup antrean lalu menunggu worker menghabiskan sisa pesan
+func (p *ingestPool) stop() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// submit memasukkan pesan ke antrean worker perangkatnya. Saat antrean penuh, policy drop langsung
+// membuang pesan baru; policy block menahan callback MQTT paling lama blockTimeout sebelum membuang,
+// karena callback yang tertahan juga menahan subscription lain dan keepalive.
+func (p *ingestPool) submit(msg *deviceMessage) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.stats.Add("dropped", 1)
+		return false
+	}
+
+	queue := p.queues[p.shard(msg.deviceID)]
+	select {
+	case queue <- msg:
+		p.stats.Add("queued", 1)
+		return true
+	default:
+	}
+
+	if p.blockTimeout <= 0 {
+		p.stats.Add("dropped", 1)
+		return false
+	}
+	p.stats.Add("blocked", 1)
+	timer := time.NewTimer(p.blockTimeout)
+	defer timer.Stop()
+	select {
+	case queue <- msg:
+		p.stats.Add("queued", 1)
+		return true
+	case <-timer.C:
+		p.stats.Add("dropped", 1)
+		return false
+	}
+}
+
+func (p *ingestPool) shard(deviceID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write(deviceID[:])
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+func (p *ingestPool) depth() int {
+	depth := 0
+	for _, queue := range p.queues {
+		depth += len(queue)
+	}
+	return depth
+}
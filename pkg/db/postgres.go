@@ -25,7 +25,22 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 	}
 
 	// Auto migrate
-	err = db.AutoMigrate(&entity.User{}, &entity.Device{})
+	err = db.AutoMigrate(
+		&entity.User{},
+		&entity.Device{},
+		&entity.AlertRule{},
+		&entity.Alert{},
+		&entity.NotificationChannel{},
+		&entity.NotificationDelivery{},
+		&entity.APIKey{},
+		&entity.DeviceGroup{},
+		&entity.DeviceCommand{},
+		&entity.DeviceShadow{},
+		&entity.Firmware{},
+		&entity.FirmwareRollout{},
+		&entity.RolloutDevice{},
+		&entity.MetricDefinition{},
+	)
 	if err != nil {
 		return nil, err
 	}
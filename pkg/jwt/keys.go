@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingKey adalah satu key dengan kid; private nil berarti key hanya untuk verifikasi (key lama hasil rotasi)
+type signingKey struct {
+	id      string
+	private interface{}
+	public  interface{}
+}
+
+// loadKey membaca file PEM berisi private key, atau public key untuk key yang sudah tidak dipakai menandatangani
+func loadKey(kid, path string, method jwt.SigningMethod) (*signingKey, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt key %q: %w", kid, err)
+	}
+
+	key := &signingKey{id: kid}
+	switch method {
+	case jwt.SigningMethodRS256:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			key.private, key.public = private, &private.PublicKey
+			return key, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA jwt key %q: %w", kid, err)
+		}
+		key.public = public
+	case jwt.SigningMethodES256:
+		if private, err := jwt.ParseECPrivateKeyFromPEM(pem); err == nil {
+			key.private, key.public = private, &private.PublicKey
+		} else {
+			public, err := jwt.ParseECPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse EC jwt key %q: %w", kid, err)
+			}
+			key.public = public
+		}
+		if key.public.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			return nil, fmt.Errorf("jwt key %q must use curve P-256 for ES256", kid)
+		}
+	}
+	return key, nil
+}
+
+// JWK adalah public key dalam format RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan semua public key yang masih berlaku. HS256 tidak mempublikasikan key apa pun.
+func (j *jwtService) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, key := range j.keys {
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.id,
+				Use: "sig",
+				Alg: j.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "EC",
+				Kid: key.id,
+				Use: "sig",
+				Alg: j.method.Alg(),
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+				Y:   base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	sort.Slice(jwks.Keys, func(a, b int) bool { return jwks.Keys[a].Kid < jwks.Keys[b].Kid })
+	return jwks
+}
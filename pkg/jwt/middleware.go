@@ -6,7 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func Middleware() fiber.Handler {
+func Middleware(service JwtService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -19,7 +19,7 @@ func Middleware() fiber.Handler {
 		}
 
 		token := parts[1]
-		claims, err := ValidateToken(token)
+		claims, err := service.ValidateToken(token)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired token"})
 		}
@@ -1,24 +1,133 @@
 package jwt
 
 import (
+	"errors"
+	"fmt"
+	"monitoring/config"
+	"strings"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type JwtService interface {
-	GenerateToken(userID string, expireDuration time.Duration) (string, error)
+	GenerateToken(claims *Claims, expireDuration time.Duration) (string, error)
 	ValidateToken(tokenStr string) (*Claims, error)
+	AccessTokenDuration() time.Duration
+	RefreshTokenDuration() time.Duration
+	JWKS() *JWKS
+}
+
+type jwtService struct {
+	method     jwt.SigningMethod
+	signingKey *signingKey
+	keys       map[string]*signingKey
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-type jwtService struct{}
+// minSecretLength adalah panjang minimal secret HS256 (256 bit, sesuai ukuran output SHA-256)
+const minSecretLength = 32
+
+// NewJwtService membuat token service dari JWTConfig.
+// HS256 memakai SecretKey, RS256/ES256 memakai PrivateKeys dengan SigningKeyID sebagai key aktif;
+// key lain tetap dipakai untuk verifikasi sehingga token lama tetap berlaku selama rotasi.
+func NewJwtService(cfg *config.JWTConfig) (JwtService, error) {
+	s := &jwtService{
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTokenDuration,
+		refreshTTL: cfg.RefreshTokenDuration,
+		keys:       make(map[string]*signingKey),
+	}
 
-func NewJwtService() JwtService {
-	return &jwtService{}
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", "HS256":
+		if cfg.SecretKey == "" {
+			return nil, errors.New("jwt secret key is required for HS256")
+		}
+		if len(cfg.SecretKey) < minSecretLength {
+			return nil, fmt.Errorf("jwt secret key must be at least %d bytes for HS256", minSecretLength)
+		}
+		s.method = jwt.SigningMethodHS256
+		s.signingKey = &signingKey{id: cfg.SigningKeyID, private: []byte(cfg.SecretKey), public: []byte(cfg.SecretKey)}
+		s.keys[cfg.SigningKeyID] = s.signingKey
+		return s, nil
+	case "RS256":
+		s.method = jwt.SigningMethodRS256
+	case "ES256":
+		s.method = jwt.SigningMethodES256
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.Algorithm)
+	}
+
+	if len(cfg.PrivateKeys) == 0 {
+		return nil, fmt.Errorf("jwt keys are required for %s", s.method.Alg())
+	}
+	for kid, path := range cfg.PrivateKeys {
+		key, err := loadKey(kid, path, s.method)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[kid] = key
+	}
+
+	s.signingKey = s.keys[cfg.SigningKeyID]
+	if s.signingKey == nil || s.signingKey.private == nil {
+		return nil, fmt.Errorf("jwt signing key %q not found or has no private key", cfg.SigningKeyID)
+	}
+	return s, nil
 }
 
-func (j *jwtService) GenerateToken(userID string, expireDuration time.Duration) (string, error) {
-	return GenerateToken(userID, expireDuration)
+// GenerateToken menandatangani claims dengan masa berlaku expireDuration.
+// ID (jti) dibuat otomatis jika kosong sehingga pemanggil bisa membacanya dari claims setelahnya.
+func (j *jwtService) GenerateToken(claims *Claims, expireDuration time.Duration) (string, error) {
+	now := time.Now()
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expireDuration))
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.Issuer = j.issuer
+
+	token := jwt.NewWithClaims(j.method, claims)
+	if j.signingKey.id != "" {
+		token.Header["kid"] = j.signingKey.id
+	}
+	return token.SignedString(j.signingKey.private)
 }
 
 func (j *jwtService) ValidateToken(tokenStr string) (*Claims, error) {
-	return ValidateToken(tokenStr)
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{j.method.Alg()}))
+	token, err := parser.ParseWithClaims(strings.TrimPrefix(tokenStr, "Bearer "), &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		return key.public, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if j.issuer != "" && !claims.VerifyIssuer(j.issuer, true) {
+		return nil, errors.New("invalid token issuer")
+	}
+
+	return claims, nil
+}
+
+func (j *jwtService) AccessTokenDuration() time.Duration {
+	return j.accessTTL
+}
+
+func (j *jwtService) RefreshTokenDuration() time.Duration {
+	return j.refreshTTL
 }
@@ -0,0 +1,352 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const segmentExt = ".seg"
+
+// Spool adalah antrean tahan restart berupa segment file append-only di satu direktori.
+// Record ditulis ke segment aktif, segment ditutup saat mencapai segmentSize dan dibaca ulang
+// berurutan dari yang tertua. Satu record adalah satu baris sehingga tidak boleh mengandung newline.
+type Spool struct {
+	mu          sync.Mutex
+	dir         string
+	segmentSize int64
+	maxSize     int64
+
+	// segments urut dari yang tertua, segment aktif (jika ada) selalu di posisi terakhir
+	segments  []*segment
+	active    *os.File
+	nextSeq   uint64
+	size      int64
+	dropped   int64
+	replaying uint64
+}
+
+type segment struct {
+	seq     uint64
+	path    string
+	size    int64
+	records int
+	created time.Time
+}
+
+type Stats struct {
+	Segments       int
+	Records        int
+	Bytes          int64
+	MaxBytes       int64
+	DroppedRecords int64
+	Oldest         time.Time
+}
+
+// Open memuat segment yang tersisa dari proses sebelumnya. Semua segment lama dianggap tertutup,
+// baris terakhir yang terpotong (proses mati di tengah penulisan) dibuang.
+func Open(dir string, segmentSize, maxSize int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	if segmentSize <= 0 || segmentSize > maxSize {
+		segmentSize = maxSize
+	}
+	s := &Spool{dir: dir, segmentSize: segmentSize, maxSize: maxSize, nextSeq: 1}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool dir: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg, err := loadSegment(filepath.Join(dir, name), seq)
+		if err != nil {
+			return nil, err
+		}
+		if seg.records == 0 {
+			os.Remove(seg.path)
+			continue
+		}
+		s.segments = append(s.segments, seg)
+		s.size += seg.size
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	if n := len(s.segments); n > 0 {
+		s.nextSeq = s.segments[n-1].seq + 1
+	}
+	return s, nil
+}
+
+func loadSegment(path string, seq uint64) (*segment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		if err := os.Truncate(path, int64(complete)); err != nil {
+			return nil, fmt.Errorf("failed to truncate spool segment: %w", err)
+		}
+	}
+	return &segment{
+		seq:     seq,
+		path:    path,
+		size:    int64(complete),
+		records: bytes.Count(data[:complete], []byte{'\n'}),
+		created: info.ModTime(),
+	}, nil
+}
+
+// Append menulis record ke segment aktif dan melakukan fsync sebelum kembali, sehingga record yang
+// sudah diterima tetap ada meski proses mati sebelum segment ditutup. Jika ukuran total melewati
+// batas, segment tertua dibuang dan jumlah record yang terbuang dikembalikan.
+func (s *Spool) Append(records ...[]byte) (int, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	var buf bytes.Buffer
+	for _, record := range records {
+		buf.Write(bytes.TrimSpace(record))
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg := s.activeSegment()
+	if seg == nil || seg.size >= s.segmentSize {
+		var err error
+		if seg, err = s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := s.active.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("failed to append spool: %w", err)
+	}
+	seg.size += int64(buf.Len())
+	seg.records += len(records)
+	s.size += int64(buf.Len())
+	if err := s.active.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+
+	return s.enforceLimit(), nil
+}
+
+// Rotate menutup segment aktif agar ikut terbaca oleh Replay
+func (s *Spool) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seal()
+}
+
+// Replay membaca segment tertutup dari yang tertua dan mengirim record lewat fn per batchSize record.
+// Segment dihapus setelah seluruh isinya terkirim; pada error pertama Replay berhenti dan segment
+// tersebut dikirim ulang dari awal pada pemanggilan berikutnya, sehingga fn harus idempoten.
+func (s *Spool) Replay(batchSize int, fn func(records [][]byte) error) (int, error) {
+	replayed := 0
+	for {
+		seg := s.oldestSealed()
+		if seg == nil {
+			return replayed, nil
+		}
+
+		n, err := replaySegment(seg.path, batchSize, fn)
+		if err != nil {
+			s.finishReplay(nil)
+			return replayed, err
+		}
+		replayed += n
+		s.finishReplay(seg)
+	}
+}
+
+func replaySegment(path string, batchSize int, fn func(records [][]byte) error) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	sent := 0
+	batch := make([][]byte, 0, batchSize)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			batch = append(batch, line)
+		}
+		if len(batch) == batchSize || (err == io.EOF && len(batch) > 0) {
+			if err := fn(batch); err != nil {
+				return sent, err
+			}
+			sent += len(batch)
+			batch = make([][]byte, 0, batchSize)
+		}
+		if err == io.EOF {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, fmt.Errorf("failed to read spool segment: %w", err)
+		}
+	}
+}
+
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := 0
+	for _, seg := range s.segments {
+		records += seg.records
+	}
+	return records
+}
+
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{
+		Segments:       len(s.segments),
+		Bytes:          s.size,
+		MaxBytes:       s.maxSize,
+		DroppedRecords: s.dropped,
+	}
+	for _, seg := range s.segments {
+		stats.Records += seg.records
+	}
+	if len(s.segments) > 0 {
+		stats.Oldest = s.segments[0].created
+	}
+	return stats
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seal()
+}
+
+func (s *Spool) activeSegment() *segment {
+	if s.active == nil {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+// rotate menutup segment aktif dan membuka segment baru
+func (s *Spool) rotate() (*segment, error) {
+	if err := s.seal(); err != nil {
+		return nil, err
+	}
+	seg := &segment{
+		seq:     s.nextSeq,
+		path:    filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.nextSeq, segmentExt)),
+		created: time.Now(),
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	s.nextSeq++
+	s.active = f
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+func (s *Spool) seal() error {
+	if s.active == nil {
+		return nil
+	}
+	f := s.active
+	s.active = nil
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	return nil
+}
+
+// enforceLimit membuang segment tertutup tertua (kecuali yang sedang di-replay) sampai ukuran di bawah batas
+func (s *Spool) enforceLimit() int {
+	dropped := 0
+	for s.size > s.maxSize {
+		idx := -1
+		for i, seg := range s.segments[:s.sealedCount()] {
+			if seg.seq != s.replaying {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			break
+		}
+		seg := s.segments[idx]
+		os.Remove(seg.path)
+		s.segments = append(s.segments[:idx], s.segments[idx+1:]...)
+		s.size -= seg.size
+		s.dropped += int64(seg.records)
+		dropped += seg.records
+	}
+	return dropped
+}
+
+// oldestSealed menandai segment tertutup tertua sebagai sedang di-replay agar tidak dibuang enforceLimit
+func (s *Spool) oldestSealed() *segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sealedCount() == 0 {
+		return nil
+	}
+	seg := s.segments[0]
+	s.replaying = seg.seq
+	return seg
+}
+
+func (s *Spool) sealedCount() int {
+	if s.active != nil {
+		return len(s.segments) - 1
+	}
+	return len(s.segments)
+}
+
+// finishReplay menghapus segment yang selesai di-replay, nil berarti replay gagal dan segment dipertahankan
+func (s *Spool) finishReplay(done *segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaying = 0
+	if done == nil {
+		return
+	}
+	for i, seg := range s.segments {
+		if seg == done {
+			os.Remove(seg.path)
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			s.size -= seg.size
+			return
+		}
+	}
+}
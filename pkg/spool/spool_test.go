@@ -0,0 +1,163 @@
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func records(prefix string, n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = []byte(fmt.Sprintf("%s-%02d", prefix, i))
+	}
+	return out
+}
+
+func replayAll(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var got []string
+	if _, err := s.Replay(100, func(batch [][]byte) error {
+		for _, record := range batch {
+			got = append(got, string(record))
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	return got
+}
+
+func TestOpenDropsTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.Append(records("a", 3)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// proses mati di tengah penulisan record berikutnya: segment aktif tidak pernah ditutup
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	f.WriteString("a-03-torn")
+	f.Close()
+
+	s, err = Open(dir, 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if n := s.Len(); n != 3 {
+		t.Fatalf("Len after reopen: got %d, want 3", n)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat segment: %v", err)
+	}
+	if stats := s.Stats(); info.Size() != stats.Bytes {
+		t.Errorf("segment not truncated: file %d bytes, spool %d bytes", info.Size(), stats.Bytes)
+	}
+
+	// record baru masuk ke segment baru, bukan menyambung baris yang terpotong
+	if _, err := s.Append([]byte("b-00")); err != nil {
+		t.Fatalf("Append after reopen: %v", err)
+	}
+	if err := s.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	want := []string{"a-00", "a-01", "a-02", "b-00"}
+	if got := replayAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestAppendEnforcesSizeLimit(t *testing.T) {
+	// setiap record 5 byte termasuk newline, segment ditutup setelah 2 record
+	s, err := Open(t.TempDir(), 10, 30)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	dropped := 0
+	for _, record := range records("r", 10) {
+		n, err := s.Append(record)
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		dropped += n
+	}
+
+	stats := s.Stats()
+	if stats.Bytes > stats.MaxBytes {
+		t.Errorf("spool size %d exceeds limit %d", stats.Bytes, stats.MaxBytes)
+	}
+	if dropped != 4 || stats.DroppedRecords != 4 {
+		t.Errorf("dropped: Append reported %d, stats %d, want 4", dropped, stats.DroppedRecords)
+	}
+	if stats.Records != 6 {
+		t.Errorf("records: got %d, want 6", stats.Records)
+	}
+
+	if err := s.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	// segment tertua yang dibuang, record terbaru tetap ada
+	want := []string{"r-04", "r-05", "r-06", "r-07", "r-08", "r-09"}
+	if got := replayAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestReplayRetriesFailedSegment(t *testing.T) {
+	s, err := Open(t.TempDir(), 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.Append(records("a", 5)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := s.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := s.Append(records("b", 2)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := s.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	errWrite := errors.New("write failed")
+	batches := 0
+	replayed, err := s.Replay(2, func(batch [][]byte) error {
+		batches++
+		if batches == 2 {
+			return errWrite
+		}
+		return nil
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("Replay error: got %v, want %v", err, errWrite)
+	}
+	if replayed != 0 {
+		t.Errorf("replayed: got %d, want 0 (segment pertama belum selesai)", replayed)
+	}
+	if n := s.Len(); n != 7 {
+		t.Fatalf("Len after failed replay: got %d, want 7", n)
+	}
+
+	// segment yang gagal dikirim ulang dari awal
+	want := []string{"a-00", "a-01", "a-02", "a-03", "a-04", "b-00", "b-01"}
+	if got := replayAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len after replay: got %d, want 0", n)
+	}
+}
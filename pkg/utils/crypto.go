@@ -1,8 +1,15 @@
 package utils
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 )
 
 func HashSHA256(input string) string {
@@ -10,3 +17,63 @@ func HashSHA256(input string) string {
 	hash.Write([]byte(input))
 	return hex.EncodeToString(hash.Sum(nil))
 }
+
+// HmacSHA256 menghasilkan signature HMAC-SHA256 (hex) dari payload dengan secret
+func HmacSHA256(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SealToPublicKey mengenkripsi plaintext untuk pemilik public key X25519 (base64, 32 byte).
+// Format hasil (base64): ephemeral public key (32) || nonce (12) || ciphertext AES-256-GCM,
+// dengan key = SHA-256(shared secret || ephemeral public key || recipient public key).
+func SealToPublicKey(recipientKey string, plaintext []byte) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(recipientKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	recipient, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", err
+	}
+
+	kdf := sha256.New()
+	kdf.Write(shared)
+	kdf.Write(ephemeral.PublicKey().Bytes())
+	kdf.Write(recipient.Bytes())
+	block, err := aes.NewCipher(kdf.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append(ephemeral.PublicKey().Bytes(), nonce...)
+	sealed = gcm.Seal(sealed, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// ValidPublicKey memeriksa public key X25519 dalam base64
+func ValidPublicKey(key string) bool {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return false
+	}
+	_, err = ecdh.X25519().NewPublicKey(raw)
+	return err == nil
+}
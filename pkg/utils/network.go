@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// blockedPrefixes adalah rentang yang tidak boleh dituju request keluar (webhook): jaringan privat,
+// link-local (termasuk metadata cloud 169.254.169.254), CGNAT dan alamat khusus lainnya
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),          // termasuk unspecified, loopback dan IPv4-compatible yang sudah usang
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 local-use
+	netip.MustParsePrefix("2001::/32"),      // Teredo, alamat IPv4 di dalamnya disamarkan
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+	netip.MustParsePrefix("fd00:ec2::254/128"),
+}
+
+var (
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+// IsPublicIP melaporkan apakah ip boleh dituju request keluar. Alamat NAT64 dan 6to4 membawa
+// alamat IPv4 di dalamnya, sehingga yang diperiksa adalah alamat IPv4 tersebut.
+func IsPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() {
+		return false
+	}
+	if embedded, ok := embeddedIPv4(ip); ok {
+		return IsPublicIP(embedded)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func embeddedIPv4(ip netip.Addr) (netip.Addr, bool) {
+	b := ip.As16()
+	switch {
+	case nat64Prefix.Contains(ip):
+		return netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]}), true
+	case sixToFour.Contains(ip):
+		return netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]}), true
+	}
+	return netip.Addr{}, false
+}
+
+// PublicDialControl dipasang di net.Dialer.Control agar alamat hasil resolve DNS diperiksa saat dial,
+// sehingga hostname yang me-resolve (atau redirect) ke jaringan internal tetap ditolak
+func PublicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(ip) {
+		return fmt.Errorf("destination %s is not a public address", ip)
+	}
+	return nil
+}